@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// httpCmd benchmarks HTTP requests with the built-in client, instead of
+// starting curl for every run
+type httpCmd struct {
+	Method       string   `arg:"" help:"Request method, such as GET or POST"`
+	URLs         []string `arg:"" name:"url" help:"URL(s) to request"`
+	Header       []string `short:"H" name:"header" sep:"none" help:"Request header, such as \"Accept: application/json\" (can be repeated)"`
+	BodyFile     string   `name:"body-file" type:"existingfile" help:"File to send as the request body"`
+	NoKeepAlive  bool     `name:"no-keepalive" help:"Open a new connection for every request, instead of reusing them"`
+	ExpectStatus []int    `name:"expect-status" sep:"," help:"Statuses of successful requests, such as 200,204 (default: any below 400)"`
+}
+
+// commands creates a command per URL
+func (h *httpCmd) commands() ([]*command.Command, error) {
+	if cli.Executor != "shell" || cli.NoShell || cli.PersistentShell || cli.Send != "" {
+		return nil, errors.New("cmdperf http doesn't take --executor, --send, --no-shell or --persistent-shell")
+	}
+
+	options := command.HTTPOptions{NoKeepAlive: h.NoKeepAlive, ExpectStatus: h.ExpectStatus}
+	if len(h.Header) > 0 {
+		options.Header = make(http.Header)
+		for _, header := range h.Header {
+			name, value, ok := strings.Cut(header, ":")
+			if name = strings.TrimSpace(name); !ok || name == "" {
+				return nil, fmt.Errorf("invalid header %q: use \"Name: value\"", header)
+			}
+			options.Header.Add(name, strings.TrimSpace(value))
+		}
+	}
+	if h.BodyFile != "" {
+		body, err := os.ReadFile(h.BodyFile)
+		if err != nil {
+			return nil, err
+		}
+		options.Body = body
+	}
+
+	commands := make([]*command.Command, len(h.URLs))
+	for i, url := range h.URLs {
+		cmd, err := command.NewHTTPRequest(strings.ToUpper(h.Method), url, options)
+		if err != nil {
+			return nil, err
+		}
+		commands[i] = cmd
+	}
+	return commands, nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -19,8 +22,10 @@ import (
 	"github.com/miklosn/cmdperf/internal/benchmark"
 	"github.com/miklosn/cmdperf/internal/command"
 	"github.com/miklosn/cmdperf/internal/output"
+	"github.com/miklosn/cmdperf/internal/setup"
 	"github.com/miklosn/cmdperf/internal/ui"
 	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
+	"golang.org/x/term"
 )
 
 var (
@@ -29,61 +34,66 @@ var (
 )
 
 var cli struct {
-	Commands         []string      `arg:"" name:"command" help:"Command(s) to benchmark" optional:""`
+	Run              runCmd        `cmd:"" default:"withargs" help:"Benchmark commands (the default)"`
+	HTTP             httpCmd       `cmd:"" name:"http" help:"Benchmark HTTP requests, such as: cmdperf http GET http://127.0.0.1:8080/health"`
 	Runs             int           `short:"n" name:"runs" help:"Number of runs to perform" default:"10"`
 	Concurrency      int           `short:"c" name:"concurrency" help:"Number of concurrent executions" default:"1"`
 	ColorScheme      string        `name:"color-scheme" help:"${color_scheme_help}" default:"auto"`
 	ListColorSchemes bool          `name:"list-color-schemes" help:"List available color schemes"`
+	Color            string        `name:"color" enum:"auto,always,never" help:"When to use colors: auto (on a terminal, unless NO_COLOR is set or CLICOLOR_FORCE forces them), always or never" default:"auto"`
 	Timeout          time.Duration `short:"t" name:"timeout" help:"Timeout for each command execution" default:"1m"`
+	KillSignal       string        `name:"kill-signal" help:"Signal sent to the process group of a run at its timeout, such as TERM; KILL without a grace period" default:"KILL"`
+	KillGrace        time.Duration `name:"kill-grace" help:"How long a run has to exit after --kill-signal before it is killed" default:"2s"`
 	Duration         time.Duration `short:"d" name:"duration" help:"Total benchmark duration (overrides --runs)"`
+	TargetPrecision  percentage    `name:"target-precision" help:"Run until the 95% confidence interval of each mean is within this much of it, such as 1% (overrides --runs)"`
+	MinRuns          int           `name:"min-runs" help:"Fewest runs per command with --target-precision" default:"10"`
+	MaxRuns          int           `name:"max-runs" help:"Most runs per command with --target-precision (0 = unlimited)"`
+	MaxTime          time.Duration `name:"max-time" help:"Longest benchmark with --target-precision (0 = unlimited)" default:"5m"`
 	Shell            string        `short:"s" name:"shell" help:"Shell to use for command execution" default:"${default_shell}"`
 	ShellOptions     []string      `name:"shell-opt" help:"Shell option (can be repeated)" default:"${default_shell_opt}"`
-	NoShell          bool          `short:"N" name:"no-shell" help:"Execute commands directly without a shell"`
+	NoShell          bool          `short:"N" name:"no-shell" help:"Execute commands directly without a shell" xor:"exec"`
+	PersistentShell  bool          `name:"persistent-shell" help:"Run commands in one long-lived shell per worker, without starting a process per run" xor:"exec"`
+	Executor         string        `name:"executor" enum:"shell,direct,http,tcp,unix" help:"What a command is: a shell command line, a command line to execute directly (as -N), an http(s) URL to GET, a tcp host:port or a unix socket path to connect to" default:"shell"`
+	Send             string        `name:"send" help:"With --executor tcp or unix, data to send once connected, timing the round trip to the first bytes of the response"`
+	Input            string        `name:"input" help:"Feed this file to the stdin of every run" xor:"input"`
+	InputFrom        string        `name:"input-from" help:"Run this command once through the shell and feed its output to the stdin of every run" xor:"input"`
+	IncludeInput     bool          `name:"include-input-write" help:"Write the input to every run through a pipe, timing the write, instead of letting the command read it from a file"`
+	ExpectExit       []string      `name:"expect-exit" sep:"none" help:"Exit statuses of successful runs, such as 0,1: once for all commands, or once per command in order (default 0)"`
+	CaptureOutput    bool          `name:"capture-output" help:"Keep the beginning of the stdout and stderr of every run, and show the output of the first failed runs"`
+	ExpectStdout     string        `name:"expect-stdout" help:"Fail runs whose stdout doesn't match: a regular expression, file:<path> with the exact output, or sha256:<hex> of it (implies --capture-output)"`
+	FailuresDir      string        `name:"failures-dir" help:"Save the output, exit status, duration and environment of failed runs to <dir>/<command>/<run>/"`
+	FailuresLimit    int           `name:"failures-limit" help:"Most failed runs saved per command with --failures-dir" default:"10"`
+	SubtractShell    bool          `name:"subtract-shell-overhead" help:"Subtract the time an empty command takes through the shell from every run"`
 	CSVOutput        string        `name:"csv" help:"Write results to CSV file"`
 	MarkdownOutput   string        `name:"markdown" help:"Write results to Markdown file"`
 	JSONOutput       string        `name:"json" help:"Write results to JSON file"`
+	CDFOutput        string        `name:"cdf" help:"Write each command's latency distribution to CSV file, at percentiles 0, 0.1, ... 100"`
+	EventsOutput     string        `name:"events" help:"Stream benchmark events to a file as JSON lines while running"`
 	Version          bool          `name:"version" help:"Show version information"`
-	FailOnError      bool          `name:"fail-on-error" help:"Exit with non-zero status if any command returns non-zero exit code"`
+	FailOnError      bool          `name:"fail-on-error" help:"Exit with non-zero status if any run fails, such as with an unexpected exit status (see --expect-exit)"`
 	CPUProfile       string        `name:"cpu-profile" help:"Write CPU profile to file"`
 	MemProfile       string        `name:"mem-profile" help:"Write memory profile to file"`
 	BlockProfile     string        `name:"block-profile" help:"Write goroutine blocking profile to file"`
 	PprofServer      bool          `name:"pprof-server" help:"Start pprof HTTP server on :6060"`
 	Rate             float64       `short:"r" name:"rate" help:"Maximum rate of requests per second per worker (0 = unlimited)"`
+	Percentiles      []float64     `name:"percentiles" sep:"," help:"Percentiles to report, such as 50,90,99,99.9" default:"50,95,99"`
+	Schedule         string        `name:"schedule" enum:"parallel,sequential,interleaved,random" help:"Order of the runs of different commands: all at once (parallel), one command after another (sequential), taking turns (interleaved) or taking turns in random order (random)" default:"parallel"`
+	Seed             int64         `name:"seed" help:"Seed of --schedule=random, to repeat an order (default: random)"`
+	Outliers         string        `name:"outliers" enum:"keep,report,drop" help:"Outliers by Tukey's fences: keep them unexamined, report them, or drop them from the statistics of the kept samples" default:"report"`
+	Progress         string        `name:"progress" enum:"auto,inline,plain,json,none" help:"Progress display: inline (live terminal UI), plain (periodic log lines), json (JSON lines), none, or auto to pick inline on a terminal and plain otherwise" default:"auto"`
+	ProgressInterval time.Duration `name:"progress-interval" help:"How often plain and json progress report (default 10s for plain, 1s for json)"`
+	GitHub           bool          `name:"github" negatable:"" help:"Write a GitHub Actions step summary, annotations and step outputs (default when GITHUB_ACTIONS is set)" default:"${github_default}"`
+	MaxMean          time.Duration `name:"max-mean" help:"Fail if any command's mean exceeds this duration"`
+	MaxP95           time.Duration `name:"max-p95" help:"Fail if any command's p95 exceeds this duration"`
 }
 
-func splitCommandRespectingQuotes(cmd string) []string {
-	var parts []string
-	var current strings.Builder
-	inQuotes := false
-	quoteChar := rune(0)
-
-	for _, r := range cmd {
-		switch {
-		case (r == '"' || r == '\'') && !inQuotes:
-			inQuotes = true
-			quoteChar = r
-		case r == quoteChar && inQuotes:
-			inQuotes = false
-			quoteChar = rune(0)
-		case r == ' ' && !inQuotes:
-			if current.Len() > 0 {
-				parts = append(parts, current.String())
-				current.Reset()
-			}
-		default:
-			current.WriteRune(r)
-		}
-	}
-
-	if current.Len() > 0 {
-		parts = append(parts, current.String())
-	}
-
-	return parts
+// runCmd benchmarks commands, the default command
+type runCmd struct {
+	Commands []string `arg:"" name:"command" help:"Command(s) to benchmark" optional:""`
 }
 
 func main() {
-	colorSchemeHelp := fmt.Sprintf("Color scheme to use (%s)", strings.Join(colorscheme.ListSchemes(), ", "))
+	colorSchemeHelp := fmt.Sprintf("Color scheme to use (%s), or a .toml scheme file", strings.Join(colorscheme.ListSchemes(), ", "))
 
 	ctx := kong.Parse(&cli,
 		kong.Name("cmdperf"),
@@ -92,8 +102,9 @@ func main() {
 		kong.Vars{
 			"version":           version,
 			"color_scheme_help": colorSchemeHelp,
-			"default_shell":     defaultShell,
-			"default_shell_opt": defaultShellOpt,
+			"default_shell":     command.DefaultShell,
+			"default_shell_opt": command.DefaultShellOption,
+			"github_default":    strconv.FormatBool(output.InGitHubActions()),
 		},
 	)
 
@@ -102,12 +113,18 @@ func main() {
 		os.Exit(0)
 	}
 
+	if err := colorscheme.SetColorMode(cli.Color); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	if cli.ListColorSchemes {
 		fmt.Print(colorscheme.FormatSchemeList())
 		os.Exit(0)
 	}
 
-	if len(cli.Commands) == 0 {
+	isHTTP := strings.HasPrefix(ctx.Command(), "http")
+	if !isHTTP && len(cli.Run.Commands) == 0 {
 		fmt.Println("Error: at least one command is required")
 		ctx.PrintUsage(false)
 		os.Exit(1)
@@ -133,85 +150,139 @@ func main() {
 		defer pprof.StopCPUProfile()
 	}
 
-	commands := make([]*command.Command, len(cli.Commands))
-	for i, cmdStr := range cli.Commands {
-		if cli.NoShell {
-			parts := splitCommandRespectingQuotes(cmdStr)
-			if len(parts) == 0 {
-				fmt.Fprintf(os.Stderr, "Error: empty command\n")
-				os.Exit(1)
-			}
-
-			commands[i] = &command.Command{
-				Raw:         cmdStr,
-				DirectExec:  true,
-				Command:     parts[0],
-				Args:        parts[1:],
-				Timeout:     cli.Timeout,
-				Parallelism: cli.Concurrency,
-			}
-
-		} else {
-			commands[i] = &command.Command{
-				Raw:          cmdStr,
-				Shell:        cli.Shell,
-				ShellOptions: cli.ShellOptions,
-				Timeout:      cli.Timeout,
-				Parallelism:  cli.Concurrency,
-			}
-		}
+	config, err := newConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	options := benchmark.Options{
-		Iterations:  cli.Runs,
-		Parallelism: cli.Concurrency,
-		Timeout:     cli.Timeout,
-		Duration:    cli.Duration,
-		Rate:        cli.Rate,
+	commands, err := newCommands(config, isHTTP)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
-
-	runner, err := benchmark.NewRunner(commands, options)
+	runner, removeInput, err := config.NewRunner(commands)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating benchmark runner: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if runner.Options.Schedule == benchmark.ScheduleRandom {
+		fmt.Fprintf(os.Stderr, "Random schedule with seed %d (--seed %d repeats it)\n", runner.Options.Seed, runner.Options.Seed)
+	}
+
 	runCtx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Receives progress from the runner and prints the final results
+	var renderer ui.Renderer
+
+	var interruptOnce sync.Once
+	interrupt := func() {
+		interruptOnce.Do(func() {
+			if renderer != nil {
+				// Use the Cancel method to properly mark the UI as cancelled
+				renderer.Cancel()
+			}
+			fmt.Fprintln(os.Stderr, "\nBenchmark interrupted, cleaning up...")
+
+			cancel()
+
+			go func() {
+				time.Sleep(2 * time.Second)
+				fmt.Fprintln(os.Stderr, "Forced exit due to slow shutdown")
+				os.Exit(1)
+			}()
+		})
+	}
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
 	go func() {
 		<-sigCh
-		fmt.Println("\nBenchmark interrupted, cleaning up...")
+		interrupt()
+	}()
 
-		if inlineUI, ok := ui.GetGlobalInlineUI(); ok {
-			// Use the Cancel method to properly mark the UI as cancelled
-			inlineUI.Cancel()
+	progressMode := cli.Progress
+	if progressMode == "auto" {
+		// Cursor movement makes a mess of CI logs and redirected output
+		progressMode = ui.ModePlain
+		if term.IsTerminal(int(os.Stdout.Fd())) {
+			progressMode = ui.ModeInline
 		}
+	}
 
-		cancel()
+	// Each command's target is only known as it converges
+	uiRuns := cli.Runs
+	if cli.TargetPrecision > 0 {
+		uiRuns = 0
+	}
 
-		go func() {
-			time.Sleep(2 * time.Second)
-			fmt.Println("Forced exit due to slow shutdown")
-			os.Exit(1)
-		}()
-	}()
+	// Sequential commands each run for the duration
+	uiDuration := cli.Duration
+	if cli.Schedule == benchmark.ScheduleSequential {
+		uiDuration *= time.Duration(len(commands))
+	}
 
-	err = ui.StartInlineUI(cli.Runs, cli.Duration, cli.ColorScheme)
+	renderer, err = ui.NewRenderer(progressMode, os.Stdout, ui.Options{
+		Runs:        uiRuns,
+		Duration:    uiDuration,
+		ColorScheme: cli.ColorScheme,
+		Interval:    cli.ProgressInterval,
+		Controller:  runner,
+		Interrupt:   interrupt,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error starting UI: %v\n", err)
+		removeInput()
 		os.Exit(1)
 	}
 
-	runner.SetProgressCallback(func(stats []*benchmark.CommandStats, complete bool) {
-		if inlineUI, ok := ui.GetGlobalInlineUI(); ok {
-			inlineUI.Update(stats, complete)
+	var observers []func(benchmark.Event)
+	var events *output.EventWriter
+	if cli.EventsOutput != "" {
+		absPath, _ := filepath.Abs(cli.EventsOutput)
+
+		file, err := os.Create(cli.EventsOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating events file at %s: %v\n", absPath, err)
+			removeInput()
+			os.Exit(1)
 		}
-	})
+		defer file.Close()
+
+		events = output.NewEventWriter(file)
+		observers = append(observers, events.Handle)
+	}
+
+	err = ui.Run(runCtx, runner, renderer, observers...)
+	removeInput()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error displaying results: %v\n", err)
+		os.Exit(1)
+	}
 
-	runner.Run(runCtx)
+	if events != nil {
+		absPath, _ := filepath.Abs(cli.EventsOutput)
+		if err := events.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing events to %s: %v\n", absPath, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Events written to %s\n", absPath)
+	}
+	if cli.FailuresDir != "" {
+		absPath, _ := filepath.Abs(cli.FailuresDir)
+		if err := runner.FailuresErr(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error saving failed runs to %s: %v\n", absPath, err)
+			os.Exit(1)
+		}
+		saved := 0
+		for _, stats := range runner.Results {
+			saved += stats.SavedFailures
+		}
+		if saved > 0 {
+			fmt.Printf("Failed runs saved to %s (%d)\n", absPath, saved)
+		}
+	}
 
 	// Release any remaining results back to the pool
 	for _, stats := range runner.Results {
@@ -284,22 +355,59 @@ func main() {
 		}
 	}
 
+	if cli.CDFOutput != "" {
+		absPath, _ := filepath.Abs(cli.CDFOutput)
+
+		file, err := os.Create(cli.CDFOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating CDF file at %s: %v\n", absPath, err)
+			os.Exit(1)
+		} else {
+			defer file.Close()
+
+			cdfWriter, _ := output.GetWriter("cdf")
+			if err := cdfWriter.Write(file, runner.Results); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing CDF to %s: %v\n", absPath, err)
+				os.Exit(1)
+			} else {
+				fmt.Printf("CDF written to %s\n", absPath)
+			}
+		}
+	}
+
+	thresholds := benchmark.Thresholds{
+		MaxMean: cli.MaxMean,
+		MaxP95:  cli.MaxP95,
+	}
+
+	if cli.GitHub {
+		gh := output.GitHubActionsFromEnv()
+		gh.Thresholds = thresholds
+		if err := gh.Write(os.Stdout, runner.Results); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing GitHub Actions results: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
 	if cli.FailOnError {
-		hasNonZeroExit := false
 		for _, stat := range runner.Results {
-			for exitCode, count := range stat.ExitCodes {
-				if exitCode != 0 && count > 0 {
-					hasNonZeroExit = true
-					break
-				}
+			if stat.Outcomes.Failed() > 0 {
+				fmt.Fprintf(os.Stderr, "Error: Some runs failed\n")
+				os.Exit(1)
 			}
-			if hasNonZeroExit {
-				break
+		}
+	}
+
+	if thresholds.Enabled() {
+		exceeded := false
+		for _, stat := range runner.Results {
+			for _, violation := range thresholds.Check(stat) {
+				fmt.Fprintf(os.Stderr, "Error: '%s': %s\n", stat.Command.Raw, violation)
+				exceeded = true
 			}
 		}
 
-		if hasNonZeroExit {
-			fmt.Fprintf(os.Stderr, "Error: Some commands returned non-zero exit codes\n")
+		if exceeded {
 			os.Exit(1)
 		}
 	}
@@ -331,3 +439,113 @@ func main() {
 		}
 	}
 }
+
+// percentage is a flag value given as "1%" or as a fraction, "0.01"
+type percentage float64
+
+func (p *percentage) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	scale := 1.0
+	if trimmed, ok := strings.CutSuffix(s, "%"); ok {
+		s, scale = trimmed, 100
+	}
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || value <= 0 || value/scale >= 1 {
+		return fmt.Errorf("invalid percentage %q: use a value such as 1%% or 0.01", string(text))
+	}
+	*p = percentage(value / scale)
+	return nil
+}
+
+// newConfig converts the flags that configure the benchmark
+func newConfig() (*setup.Config, error) {
+	config := &setup.Config{
+		Runner: benchmark.Options{
+			Iterations:  cli.Runs,
+			Parallelism: cli.Concurrency,
+			Timeout:     cli.Timeout,
+			Duration:    cli.Duration,
+			Rate:        cli.Rate,
+			Outliers:    cli.Outliers,
+			Percentiles: cli.Percentiles,
+			Schedule:    cli.Schedule,
+			Seed:        cli.Seed,
+
+			SubtractShellOverhead: cli.SubtractShell,
+
+			TargetPrecision: float64(cli.TargetPrecision),
+			MinRuns:         cli.MinRuns,
+			MaxRuns:         cli.MaxRuns,
+			MaxTime:         cli.MaxTime,
+
+			FailuresDir:   cli.FailuresDir,
+			FailuresLimit: cli.FailuresLimit,
+		},
+
+		Shell:           cli.Shell,
+		ShellOptions:    cli.ShellOptions,
+		NoShell:         cli.NoShell || cli.Executor == "direct",
+		PersistentShell: cli.PersistentShell,
+
+		KillGrace: cli.KillGrace,
+
+		Input:             cli.Input,
+		InputFrom:         cli.InputFrom,
+		IncludeInputWrite: cli.IncludeInput,
+
+		CaptureOutput: cli.CaptureOutput,
+		ExpectStdout:  cli.ExpectStdout,
+	}
+
+	sig, err := command.ParseSignal(cli.KillSignal)
+	if err != nil {
+		return nil, fmt.Errorf("--kill-signal: %w", err)
+	}
+	config.KillSignal = sig
+
+	for _, spec := range cli.ExpectExit {
+		codes, err := command.ParseExitCodes(spec)
+		if err != nil {
+			return nil, fmt.Errorf("--expect-exit: %w", err)
+		}
+		config.ExpectExit = append(config.ExpectExit, codes)
+	}
+	return config, nil
+}
+
+// newCommands creates the commands to benchmark, from the URLs of
+// cmdperf http or the command arguments
+func newCommands(config *setup.Config, isHTTP bool) ([]*command.Command, error) {
+	if isHTTP {
+		return cli.HTTP.commands()
+	}
+	commands := make([]*command.Command, len(cli.Run.Commands))
+	for i, raw := range cli.Run.Commands {
+		cmd, err := newCommand(config, raw)
+		if err != nil {
+			return nil, err
+		}
+		commands[i] = cmd
+	}
+	return commands, nil
+}
+
+// newCommand creates the command raw stands for with --executor
+func newCommand(config *setup.Config, raw string) (*command.Command, error) {
+	if (cli.NoShell || cli.PersistentShell) && cli.Executor != "shell" {
+		return nil, fmt.Errorf("--executor=%s can't be used with --no-shell or --persistent-shell", cli.Executor)
+	}
+	if cli.Send != "" && cli.Executor != "tcp" && cli.Executor != "unix" {
+		return nil, errors.New("--send needs --executor tcp or unix")
+	}
+
+	switch cli.Executor {
+	case "http":
+		return command.NewHTTP(raw)
+	case "tcp":
+		return command.NewTCP(raw, []byte(cli.Send))
+	case "unix":
+		return command.NewUnix(raw, []byte(cli.Send))
+	}
+	return config.Command(raw)
+}
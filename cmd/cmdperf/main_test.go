@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMain(m *testing.M) {
+	// The test binary runs as cmdperf when cmdperf runs it
+	if os.Getenv("CMDPERF_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCmdperf runs cmdperf with args and returns its exit code and stderr
+func runCmdperf(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+
+	cmd := exec.Command(os.Args[0], args...)
+	cmd.Env = append(os.Environ(), "CMDPERF_TEST_MAIN=1", "GITHUB_ACTIONS=")
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("Failed to run cmdperf: %v", err)
+	}
+	return cmd.ProcessState.ExitCode(), stderr.String()
+}
+
+func TestThresholdsExitCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		code   int
+		stderr string
+	}{
+		{"within", []string{"--max-mean", "1m", "--max-p95", "1m", "true"}, 0, ""},
+		{"mean", []string{"--max-mean", "1ns", "true"}, 1, "Error: 'true': mean "},
+		{"p95", []string{"--max-p95", "1ns", "true"}, 1, "Error: 'true': p95 "},
+		{"no successful runs", []string{"--shell", "/nonexistent/shell", "--max-mean", "1m", "true"}, 1, "no successful runs"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, stderr := runCmdperf(t, append([]string{"-n", "3"}, test.args...)...)
+			if code != test.code {
+				t.Errorf("Exit code %d, want %d; stderr:\n%s", code, test.code, stderr)
+			}
+			if !strings.Contains(stderr, test.stderr) {
+				t.Errorf("Stderr %q, want it to contain %q", stderr, test.stderr)
+			}
+		})
+	}
+}
@@ -1,8 +0,0 @@
-//go:build !windows
-
-package main
-
-const (
-	defaultShell    = "/bin/sh"
-	defaultShellOpt = "-c"
-)
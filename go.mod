@@ -3,17 +3,16 @@ module github.com/miklosn/cmdperf
 go 1.21.0
 
 require (
+	github.com/BurntSushi/toml v1.6.0
 	github.com/alecthomas/kong v0.8.1
-	github.com/fatih/color v1.18.0
 	github.com/muesli/termenv v0.16.0
+	golang.org/x/sys v0.30.0
 	golang.org/x/term v0.17.0
 )
 
 require (
 	github.com/aymanbagabas/go-osc52/v2 v2.0.1 // indirect
 	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
-	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/rivo/uniseg v0.4.7 // indirect
-	golang.org/x/sys v0.30.0 // indirect
 )
@@ -0,0 +1,77 @@
+package benchmark
+
+import (
+	"slices"
+	"time"
+)
+
+// ActivityWindow is the number of one-second buckets of recent activity kept
+// per command
+const ActivityWindow = 60
+
+type activityBucket struct {
+	second int64
+	count  int
+	sum    time.Duration
+}
+
+// Activity tracks completions and latency per second over the last
+// ActivityWindow seconds, in a fixed ring so memory stays constant however
+// long the benchmark runs.
+type Activity struct {
+	buckets [ActivityWindow]activityBucket
+}
+
+// Add records a run that finished at end and took duration
+func (a *Activity) Add(end time.Time, duration time.Duration) {
+	second := end.Unix()
+	b := &a.buckets[second%ActivityWindow]
+	if b.second > second {
+		// Too old, the bucket has moved on to a newer second
+		return
+	}
+	if b.second != second {
+		*b = activityBucket{second: second}
+	}
+	b.count++
+	b.sum += duration
+}
+
+// Series returns per-second throughput (runs/s) and mean latency for the n
+// whole seconds before now, oldest first. Seconds without any completed run
+// have zero latency.
+func (a *Activity) Series(now time.Time, n int) (throughput []float64, latency []time.Duration) {
+	if n > ActivityWindow-1 {
+		n = ActivityWindow - 1
+	}
+	if n <= 0 {
+		return nil, nil
+	}
+
+	throughput = make([]float64, n)
+	latency = make([]time.Duration, n)
+	last := now.Unix() - 1 // the current second is still filling up
+	for i := 0; i < n; i++ {
+		second := last - int64(n-1-i)
+		b := a.buckets[second%ActivityWindow]
+		if b.second != second || b.count == 0 {
+			continue
+		}
+		throughput[i] = float64(b.count)
+		latency[i] = b.sum / time.Duration(b.count)
+	}
+	return throughput, latency
+}
+
+// Charts returns copies of what live charts of s draw while the benchmark
+// runs: the sampled durations, and the n seconds before now of Activity.
+// Unlike reading them directly, it is safe while runs are being added.
+func (s *CommandStats) Charts(now time.Time, n int) (samples []time.Duration, throughput []float64, latency []time.Duration) {
+	if s.lock != nil {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+	}
+	samples = slices.Clone(s.MedianSamples)
+	throughput, latency = s.Activity.Series(now, n)
+	return samples, throughput, latency
+}
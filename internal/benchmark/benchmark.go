@@ -3,12 +3,14 @@ package benchmark
 import (
 	"context"
 	"errors"
+	"fmt"
 	"math"
 	"math/rand"
 	"runtime"
 	"runtime/debug"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/miklosn/cmdperf/internal/command"
@@ -41,6 +43,42 @@ type Options struct {
 
 	// Rate limiting option (requests per second per worker)
 	Rate float64
+
+	// Outliers is OutliersKeep, OutliersReport or OutliersDrop. Empty is
+	// OutliersReport.
+	Outliers string
+
+	// SubtractShellOverhead subtracts the overhead of starting the shell,
+	// measured before the benchmark, from every run of commands that use one
+	SubtractShellOverhead bool
+
+	// Schedule is ScheduleParallel, ScheduleSequential, ScheduleInterleaved
+	// or ScheduleRandom. Empty is ScheduleParallel. Seed seeds
+	// ScheduleRandom; NewRunner picks one when it is zero, so that the order
+	// can be repeated.
+	Schedule string
+	Seed     int64
+
+	// Percentiles to report, such as 99.9, DefaultPercentiles when empty.
+	// Enough samples are kept for the highest of them to be measured rather
+	// than be the slowest run.
+	Percentiles []float64
+
+	// TargetPrecision runs every command until the 95% confidence interval
+	// of its mean is within ±TargetPrecision of the mean (0.01 for 1%),
+	// instead of Iterations times or for Duration. It takes at least
+	// MinRuns runs (DefaultMinRuns when zero) and stops at MaxRuns runs or
+	// after MaxTime whether or not it got there; zero means no limit.
+	TargetPrecision float64
+	MinRuns         int
+	MaxRuns         int
+	MaxTime         time.Duration
+
+	// FailuresDir, when set, is where the first FailuresLimit failed runs
+	// of every command are saved, DefaultFailuresLimit when zero; see
+	// Runner.FailuresErr
+	FailuresDir   string
+	FailuresLimit int
 }
 
 // BenchmarkMode represents the mode of benchmarking
@@ -51,8 +89,19 @@ const (
 	ModeIterations BenchmarkMode = iota
 	// ModeDuration runs the benchmark for a fixed duration
 	ModeDuration
+	// ModePrecision runs each command until its mean is known to
+	// Options.TargetPrecision
+	ModePrecision
 )
 
+// DefaultMinRuns is the fewest runs ModePrecision takes by default, so the
+// confidence interval isn't judged from a handful of lucky samples
+const DefaultMinRuns = 10
+
+// DefaultMaxTime is how long ModePrecision runs at most by default, for
+// commands that never settle
+const DefaultMaxTime = 5 * time.Minute
+
 // CommandStats holds statistics for a single command
 type CommandStats struct {
 	// Command that was benchmarked
@@ -61,6 +110,13 @@ type CommandStats struct {
 	// Store only a fixed number of recent results to prevent unbounded memory growth
 	RecentResults []*command.Result
 
+	// Number of runs the command is expected to reach in iteration mode.
+	// Starts at Options.Iterations and grows when the benchmark is extended.
+	TargetRuns int
+
+	// Skipped is set when the command was stopped early with Runner.Skip
+	Skipped bool
+
 	// Running statistics
 	TotalRuns      int
 	SuccessfulRuns int
@@ -69,29 +125,94 @@ type CommandStats struct {
 	// Exit code tracking
 	ExitCodes map[int]int // Maps exit code to count
 
+	// Outcomes counts the runs by how they ended. FailedRuns keeps the
+	// output of the first MaxFailedRuns failed runs of commands with
+	// captured output.
+	Outcomes   Outcomes
+	FailedRuns []FailedRun
+
+	// SavedFailures is the number of failed runs saved to
+	// Options.FailuresDir
+	SavedFailures int
+
 	// Summary statistics
 	Min, Max, Mean, Median, StdDev time.Duration
 
-	// Percentile statistics
+	// Percentile statistics. P50, P95 and P99 are always calculated,
+	// Percentiles are the ones in Options.Percentiles in ascending order.
 	P50, P95, P99 time.Duration
+	Percentiles   []Percentile
 
 	HighVariance bool
 
+	// Outliers among the samples, nil with OutliersKeep or before the
+	// benchmark completes
+	Outliers *Outliers
+
+	// Modes of the latency distribution, nil with fewer than MinModeSamples
+	// samples or before the benchmark completes
+	Modes Modes
+
+	// ShellOverhead is the cost of starting the command's shell, measured
+	// before the benchmark; nil for commands without a shell.
+	// MostlyShellOverhead is set when the command's own time, beyond the
+	// shell's, is within the noise of the shell's.
+	ShellOverhead       *ShellOverhead
+	MostlyShellOverhead bool
+
+	// Drift of latency over the benchmark, nil with fewer than MinDriftRuns
+	// runs or before the benchmark completes
+	Drift *Drift
+
+	// Phases of the command's HTTP requests, nil for other commands or
+	// before the benchmark completes
+	Phases    []Phase
+	phaseSums phaseSums
+
+	// Durations in the order the runs completed, which Drift is found in
+	timeline timeline
+
 	// Running sum for incremental mean calculation
 	RunningSum time.Duration
 
-	// Representative sample of durations for median calculation
+	// Representative sample of durations for median and percentile
+	// calculation, of at most sampleLimit durations
 	MedianSamples []time.Duration
+	sampleLimit   int
 
 	// Throughput in operations per second
 	Throughput float64
 
+	// InputBytes is the size of the input fed to every run, and
+	// InputThroughput the rate it was fed at over all runs in bytes per
+	// second; zero without command.Stdin
+	InputBytes      int64
+	InputThroughput float64
+
 	// Target rate from options
 	TargetRate float64
 
+	// In ModePrecision, the requested precision and the current half-width
+	// of the mean's 95% confidence interval relative to the mean. Converged
+	// is set once Precision reached TargetPrecision.
+	TargetPrecision float64
+	Precision       float64
+	Converged       bool
+
+	// Running variance of all successful runs (Welford's algorithm), which
+	// Precision is calculated from
+	welfordMean, welfordM2 float64
+
 	// Timestamps for throughput calculation
 	FirstStartTime time.Time
 	LastEndTime    time.Time
+
+	// Per-second throughput and latency over the last minute, for live charts
+	Activity Activity
+
+	// lock is the Runner's statsMutex, which guards the statistics while
+	// the benchmark runs, see Charts
+	lock *sync.Mutex
 }
 
 // Runner coordinates the benchmark execution
@@ -104,7 +225,27 @@ type Runner struct {
 	wg               sync.WaitGroup
 	statsMutex       sync.Mutex
 	progressCallback func(stats []*CommandStats, complete bool)
-	eventHandler     func(event interface{})
+	eventHandler     func(event Event)
+	startTime        time.Time
+
+	// Interactive control state, see control.go
+	controlMu sync.Mutex
+	controlCh chan struct{}
+	resumeCh  chan struct{} // non-nil while paused
+	paused    atomic.Bool
+	pausedAt  time.Time
+	deadline  time.Time // end of the benchmark in duration mode, or MaxTime in precision mode
+	stop      context.CancelFunc
+	stopped   bool
+	queues    []*workQueue
+	cancels   []context.CancelFunc
+
+	// Turns taken by the commands with ScheduleInterleaved and
+	// ScheduleRandom, nil otherwise
+	turns *turns
+
+	// First error saving a failed run, see FailuresErr
+	failuresErr error
 }
 
 // NewRunner creates a new benchmark runner with validation
@@ -113,8 +254,8 @@ func NewRunner(commands []*command.Command, options Options) (*Runner, error) {
 	if len(commands) == 0 {
 		return nil, errors.New("benchmark: at least one command is required")
 	}
-	if options.Iterations <= 0 && options.Duration <= 0 {
-		return nil, errors.New("benchmark: iterations or duration must be positive")
+	if options.Iterations <= 0 && options.Duration <= 0 && options.TargetPrecision <= 0 {
+		return nil, errors.New("benchmark: iterations, duration or target precision must be positive")
 	}
 	if options.Parallelism <= 0 {
 		return nil, errors.New("benchmark: parallelism must be positive")
@@ -124,12 +265,60 @@ func NewRunner(commands []*command.Command, options Options) (*Runner, error) {
 	if options.Duration > 0 {
 		mode = ModeDuration
 	}
+	if options.TargetPrecision > 0 {
+		mode = ModePrecision
+		if options.Duration > 0 {
+			return nil, errors.New("benchmark: target precision and duration can't be combined")
+		}
+		if options.TargetPrecision >= 1 {
+			return nil, errors.New("benchmark: target precision must be below 100%")
+		}
+		if options.MinRuns <= 0 {
+			options.MinRuns = DefaultMinRuns
+		}
+		if options.MinRuns < 2 {
+			options.MinRuns = 2
+		}
+		if options.MaxRuns > 0 && options.MaxRuns < options.MinRuns {
+			return nil, errors.New("benchmark: max runs must not be below min runs")
+		}
+	}
+	switch options.Outliers {
+	case "":
+		options.Outliers = OutliersReport
+	case OutliersKeep, OutliersReport, OutliersDrop:
+	default:
+		return nil, fmt.Errorf("benchmark: invalid outlier mode %q", options.Outliers)
+	}
+	switch options.Schedule {
+	case "":
+		options.Schedule = ScheduleParallel
+	case ScheduleRandom:
+		if options.Seed == 0 {
+			options.Seed = time.Now().UnixNano()
+		}
+	case ScheduleParallel, ScheduleSequential, ScheduleInterleaved:
+	default:
+		return nil, fmt.Errorf("benchmark: invalid schedule %q", options.Schedule)
+	}
+	if options.FailuresLimit < 0 {
+		return nil, errors.New("benchmark: failures limit must not be negative")
+	}
+	if options.FailuresLimit == 0 {
+		options.FailuresLimit = DefaultFailuresLimit
+	}
+	percentiles, err := normalizePercentiles(options.Percentiles)
+	if err != nil {
+		return nil, err
+	}
+	options.Percentiles = percentiles
 
 	return &Runner{
-		Options:  options,
-		Commands: commands,
-		Results:  make([]*CommandStats, len(commands)),
-		Mode:     mode,
+		Options:   options,
+		Commands:  commands,
+		Results:   make([]*CommandStats, len(commands)),
+		Mode:      mode,
+		controlCh: make(chan struct{}, 1),
 	}, nil
 }
 
@@ -138,8 +327,9 @@ func (r *Runner) SetProgressCallback(callback func(stats []*CommandStats, comple
 	r.progressCallback = callback
 }
 
-// SetEventHandler sets a handler function for benchmark events
-func (r *Runner) SetEventHandler(handler func(event interface{})) {
+// SetEventHandler sets a handler function for benchmark events, see Event.
+// It is called from several goroutines.
+func (r *Runner) SetEventHandler(handler func(event Event)) {
 	r.eventHandler = handler
 }
 
@@ -155,36 +345,55 @@ func (runner *Runner) Run(ctx context.Context) {
 	defer debug.SetGCPercent(prev)
 
 	// Initialize results with pre-allocated capacity
+	limit := sampleLimit(runner.Options.Percentiles)
 	for i := range runner.Commands {
 		runner.Results[i] = &CommandStats{
 			Command:       runner.Commands[i],
+			TargetRuns:    runner.Options.Iterations,
 			RecentResults: make([]*command.Result, 0, MaxRecentResults),
-			MedianSamples: make([]time.Duration, 0, 1000),
+			MedianSamples: make([]time.Duration, 0, MaxMedianSamples),
+			sampleLimit:   limit,
 			ExitCodes:     make(map[int]int), // Initialize exit code map
+			lock:          &runner.statsMutex,
+		}
+		if in := runner.Commands[i].Stdin; in != nil {
+			runner.Results[i].InputBytes = in.Size
+		}
+		if runner.Mode == ModePrecision {
+			// Refined as the confidence interval narrows
+			runner.Results[i].TargetRuns = runner.Options.MinRuns
+			runner.Results[i].TargetPrecision = runner.Options.TargetPrecision
 		}
 	}
 
-	// Emit benchmark started event
-	if runner.eventHandler != nil {
-		runner.eventHandler(map[string]interface{}{
-			"type":     "benchmark_started",
-			"commands": runner.Commands,
-			"options":  runner.Options,
-		})
-	}
+	runner.calibrateShells(ctx)
+
+	runner.startTime = time.Now()
+	runner.emitBenchmarkStarted()
 
 	// Create a context for the benchmark
-	var benchCtx context.Context
-	var benchCancel context.CancelFunc
+	benchCtx, benchCancel := context.WithCancel(ctx)
+	defer benchCancel()
 
-	if runner.Options.Duration > 0 {
-		// If duration is set, create a context with timeout
-		benchCtx, benchCancel = context.WithTimeout(ctx, runner.Options.Duration)
-	} else {
-		// Otherwise use the parent context
-		benchCtx, benchCancel = context.WithCancel(ctx)
+	runner.controlMu.Lock()
+	runner.stop = benchCancel
+	runner.queues = make([]*workQueue, len(runner.Commands))
+	runner.cancels = make([]context.CancelFunc, len(runner.Commands))
+	timeLimit := runner.timeLimit()
+	if runner.Mode == ModeDuration && runner.Options.Schedule == ScheduleSequential {
+		// Every command has a deadline of its own, see runSequentially
+		timeLimit = 0
+	}
+	if timeLimit > 0 {
+		runner.deadline = time.Now().Add(timeLimit)
+	}
+	runner.controlMu.Unlock()
+
+	if timeLimit > 0 {
+		// The deadline moves when the benchmark is paused or extended, so it
+		// is enforced by hand rather than with context.WithTimeout
+		go runner.enforceDeadline(benchCtx, benchCancel)
 	}
-	defer benchCancel()
 
 	// Start a timer to ensure regular UI updates even for slow commands
 	updateTicker := time.NewTicker(500 * time.Millisecond)
@@ -204,10 +413,26 @@ func (runner *Runner) Run(ctx context.Context) {
 		}
 	}()
 
-	// Launch a goroutine for each command
-	for cmdIndex, command := range runner.Commands {
+	switch runner.Options.Schedule {
+	case ScheduleInterleaved:
+		runner.turns = newTurns(len(runner.Commands), runner.Options.Parallelism, nil)
+	case ScheduleRandom:
+		rng := rand.New(rand.NewSource(runner.Options.Seed))
+		runner.turns = newTurns(len(runner.Commands), runner.Options.Parallelism, rng)
+	}
+
+	if runner.Options.Schedule == ScheduleSequential {
 		runner.wg.Add(1)
-		go runner.runCommand(benchCtx, cmdIndex, command)
+		go func() {
+			defer runner.wg.Done()
+			runner.runSequentially(benchCtx)
+		}()
+	} else {
+		// Launch a goroutine for each command
+		for cmdIndex, command := range runner.Commands {
+			runner.wg.Add(1)
+			go runner.runCommand(benchCtx, cmdIndex, command)
+		}
 	}
 
 	// Wait for all benchmarks to complete
@@ -221,15 +446,36 @@ func (runner *Runner) Run(ctx context.Context) {
 		// Recalculate standard deviation for final results
 		calculateStdDev(stats)
 
+		// Ensure median is up-to-date by sorting samples
+		sortMedianSamples(stats)
+
+		// Before outliers are dropped, since a small mode looks like them
+		stats.Modes = findModes(stats.MedianSamples)
+
+		if runner.Options.Outliers != OutliersKeep {
+			var inliers []time.Duration
+			stats.Outliers, inliers = classifyOutliers(stats.MedianSamples)
+			if runner.Options.Outliers == OutliersDrop {
+				dropOutliers(stats, inliers)
+			}
+		}
+
 		if stats.StdDev > 0 && stats.Mean > 0 && float64(stats.StdDev)/float64(stats.Mean) > 0.2 {
 			stats.HighVariance = true
 		}
 
-		// Ensure median is up-to-date by sorting samples
+		stats.Drift = analyzeDrift(&stats.timeline)
+		stats.Phases = stats.phaseSums.phases()
+
+		if o := stats.ShellOverhead; o != nil && stats.SuccessfulRuns > 0 {
+			own := stats.Mean
+			if !o.Subtracted {
+				own -= o.Mean
+			}
+			stats.MostlyShellOverhead = own < o.noise()
+		}
+
 		if len(stats.MedianSamples) > 0 {
-			sort.Slice(stats.MedianSamples, func(i, j int) bool {
-				return stats.MedianSamples[i] < stats.MedianSamples[j]
-			})
 
 			// Get median from sorted samples
 			midIdx := len(stats.MedianSamples) / 2
@@ -239,20 +485,13 @@ func (runner *Runner) Run(ctx context.Context) {
 				stats.Median = (stats.MedianSamples[midIdx-1] + stats.MedianSamples[midIdx]) / 2
 			}
 
-			percentile := func(p float64) time.Duration {
-				n := len(stats.MedianSamples)
-				if n == 0 {
-					return 0
-				}
-				idx := int(p * float64(n))
-				if idx >= n {
-					idx = n - 1
-				}
-				return stats.MedianSamples[idx]
-			}
-			stats.P50 = percentile(0.50)
-			stats.P95 = percentile(0.95)
-			stats.P99 = percentile(0.99)
+			stats.P50 = percentileOf(stats.MedianSamples, 50)
+			stats.P95 = percentileOf(stats.MedianSamples, 95)
+			stats.P99 = percentileOf(stats.MedianSamples, 99)
+		}
+		stats.Percentiles = make([]Percentile, len(runner.Options.Percentiles))
+		for i, p := range runner.Options.Percentiles {
+			stats.Percentiles[i] = Percentile{P: p, Value: percentileOf(stats.MedianSamples, p)}
 		}
 	}
 
@@ -261,13 +500,65 @@ func (runner *Runner) Run(ctx context.Context) {
 		runner.progressCallback(runner.Results, true)
 	}
 
-	// Emit benchmark completed event
-	if runner.eventHandler != nil {
-		runner.eventHandler(map[string]interface{}{
-			"type":    "benchmark_completed",
-			"results": runner.Results,
-		})
+	runner.emitBenchmarkCompleted(ctx)
+}
+
+// timeLimit returns how long the benchmark may run, zero for no limit
+func (runner *Runner) timeLimit() time.Duration {
+	switch runner.Mode {
+	case ModeDuration:
+		return runner.Options.Duration
+	case ModePrecision:
+		return runner.Options.MaxTime
+	default:
+		return 0
+	}
+}
+
+// emitBenchmarkStarted emits a benchmark started event
+func (runner *Runner) emitBenchmarkStarted() {
+	if runner.eventHandler == nil {
+		return
+	}
+
+	commands := make([]string, len(runner.Commands))
+	for i, cmd := range runner.Commands {
+		commands[i] = cmd.Raw
+	}
+
+	event := &BenchmarkStarted{
+		Time:        runner.startTime,
+		Commands:    commands,
+		Duration:    runner.Options.Duration,
+		Parallelism: runner.Options.Parallelism,
+		Rate:        runner.Options.Rate,
+		Schedule:    runner.Options.Schedule,
+		Seed:        runner.Options.Seed,
+	}
+	if runner.Mode == ModeIterations {
+		event.Iterations = runner.Options.Iterations
+	}
+	runner.eventHandler(event)
+}
+
+// emitBenchmarkCompleted emits a benchmark completed event
+func (runner *Runner) emitBenchmarkCompleted(ctx context.Context) {
+	if runner.eventHandler == nil {
+		return
 	}
+
+	results := make([]Snapshot, len(runner.Results))
+	for i, stats := range runner.Results {
+		results[i] = stats.Snapshot()
+	}
+
+	runner.eventHandler(&BenchmarkCompleted{
+		Time:        time.Now(),
+		Elapsed:     time.Since(runner.startTime),
+		Stopped:     runner.Stopped(),
+		Interrupted: ctx.Err() != nil,
+		Results:     results,
+	})
 }
 
 // emitCommandStarted emits a command started event
@@ -276,15 +567,15 @@ func (runner *Runner) emitCommandStarted(index int, cmd *command.Command) {
 		return
 	}
 
-	runner.eventHandler(map[string]interface{}{
-		"type":          "command_started",
-		"command_index": index,
-		"command":       cmd,
+	runner.eventHandler(&CommandStarted{
+		Time:    time.Now(),
+		Index:   index,
+		Command: cmd.Raw,
 	})
 }
 
 // emitCommandProgress emits a command progress event
-func (runner *Runner) emitCommandProgress(index int, cmd *command.Command, result *command.Result, completed, total int, elapsed time.Duration) {
+func (runner *Runner) emitCommandProgress(index int, result *command.Result, completed, total int, elapsed time.Duration) {
 	if runner.eventHandler == nil {
 		return
 	}
@@ -305,49 +596,46 @@ func (runner *Runner) emitCommandProgress(index int, cmd *command.Command, resul
 	}
 
 	runner.statsMutex.Lock()
-	statsCopy := *runner.Results[index]
+	snapshot := runner.Results[index].Snapshot()
+	if runner.Mode == ModePrecision {
+		total = runner.Results[index].TargetRuns
+		progress = math.Min(1, float64(completed)/float64(total))
+	}
 	runner.statsMutex.Unlock()
 
-	// Create event data
-	eventData := map[string]interface{}{
-		"type":          "command_progress",
-		"command_index": index,
-		"command":       cmd,
-		"stats":         statsCopy,
-		"progress":      progress,
-		"completed":     completed,
-		"total":         total,
-		"elapsed":       elapsed,
-		"mode":          runner.Mode,
-	}
-
-	if runner.Mode == ModeDuration {
-		eventData["duration"] = runner.Options.Duration
+	event := &CommandProgress{
+		Time:      time.Now(),
+		Index:     index,
+		Completed: completed,
+		Total:     total,
+		Elapsed:   elapsed,
+		Progress:  progress,
+		Stats:     snapshot,
 	}
 
 	// Only include result if not nil
 	if result != nil {
-		eventData["result"] = result
+		event.Result = newRunResult(result)
 	}
 
-	runner.eventHandler(eventData)
+	runner.eventHandler(event)
 }
 
 // emitCommandCompleted emits a command completed event
-func (runner *Runner) emitCommandCompleted(index int, cmd *command.Command) {
+func (runner *Runner) emitCommandCompleted(index int, elapsed time.Duration) {
 	if runner.eventHandler == nil {
 		return
 	}
 
 	runner.statsMutex.Lock()
-	statsCopy := *runner.Results[index]
+	snapshot := runner.Results[index].Snapshot()
 	runner.statsMutex.Unlock()
 
-	runner.eventHandler(map[string]interface{}{
-		"type":          "command_completed",
-		"command_index": index,
-		"command":       cmd,
-		"stats":         statsCopy,
+	runner.eventHandler(&CommandCompleted{
+		Time:    time.Now(),
+		Index:   index,
+		Elapsed: elapsed,
+		Stats:   snapshot,
 	})
 }
 
@@ -369,9 +657,31 @@ func (runner *Runner) processBatch(cmdIndex int, resultBatch []*command.Result)
 		// Update statistics incrementally
 		updateStatsIncrementally(cmdStats, result)
 
+		done := false
+		if runner.Mode == ModePrecision {
+			done = runner.updatePrecisionTarget(cmdStats)
+		}
+
+		save := runner.shouldSaveFailure(cmdStats, result)
+		run := cmdStats.TotalRuns
+
 		// Unlock before calling the callback to avoid deadlocks
 		runner.statsMutex.Unlock()
 
+		if save {
+			runner.saveFailure(cmdIndex, run, result)
+		}
+
+		if done {
+			// Let the runs in flight finish, but start no more
+			runner.controlMu.Lock()
+			queue := runner.queues[cmdIndex]
+			runner.controlMu.Unlock()
+			if queue != nil {
+				queue.close()
+			}
+		}
+
 		// Report progress after EACH result to show progress as soon as possible
 		// This is especially important for the first few results
 		if runner.progressCallback != nil {
@@ -408,6 +718,7 @@ func updateStatsIncrementally(stats *CommandStats, newResult *command.Result) {
 
 	// Track exit code
 	stats.ExitCodes[newResult.ExitCode]++
+	stats.Outcomes.add(newResult)
 
 	// Check for errors
 	if newResult.Error != nil {
@@ -419,6 +730,7 @@ func updateStatsIncrementally(stats *CommandStats, newResult *command.Result) {
 
 		if !isDurationTimeout {
 			stats.ErrorCount++
+			stats.addFailure(newResult)
 		}
 
 		// Timeouts and spawn failures carry no valid timing sample
@@ -432,7 +744,11 @@ func updateStatsIncrementally(stats *CommandStats, newResult *command.Result) {
 	stats.SuccessfulRuns++
 
 	// Get the duration
-	duration := newResult.Duration
+	duration := stats.sample(newResult)
+
+	if newResult.Phases.TTFB > 0 {
+		stats.phaseSums.add(newResult.Phases, duration)
+	}
 
 	// Update min/max
 	if stats.SuccessfulRuns == 1 || duration < stats.Min {
@@ -448,9 +764,19 @@ func updateStatsIncrementally(stats *CommandStats, newResult *command.Result) {
 
 	// Update median samples (reservoir sampling)
 	updateMedianSamples(stats, duration)
+	stats.timeline.add(duration)
+
+	// Update the running variance, and the precision it gives the mean
+	delta := float64(duration) - stats.welfordMean
+	stats.welfordMean += delta / float64(stats.SuccessfulRuns)
+	stats.welfordM2 += delta * (float64(duration) - stats.welfordMean)
+	if stats.TargetPrecision > 0 {
+		stats.Precision = relativeCI(stats.welfordMean, stats.welfordM2, stats.SuccessfulRuns)
+	}
 
 	// Update throughput calculation
 	updateThroughputStats(stats, newResult)
+	stats.Activity.Add(newResult.StartTime.Add(newResult.Duration), duration)
 
 	// Periodically recalculate standard deviation (more expensive)
 	if stats.SuccessfulRuns%100 == 0 || stats.SuccessfulRuns <= 10 {
@@ -458,9 +784,79 @@ func updateStatsIncrementally(stats *CommandStats, newResult *command.Result) {
 	}
 }
 
+// relativeCI returns the half-width of the 95% confidence interval of a mean,
+// relative to the mean, from n samples with the given mean and sum of squared
+// deviations. It is 0 until there are two samples.
+func relativeCI(mean, m2 float64, n int) float64 {
+	if n < 2 || mean <= 0 {
+		return 0
+	}
+	stdErr := math.Sqrt(m2/float64(n-1)) / math.Sqrt(float64(n))
+	return studentT975(n-1) * stdErr / mean
+}
+
+// studentT975Table holds the 97.5th percentiles of Student's t-distribution with
+// 1 to 30 degrees of freedom
+var studentT975Table = [...]float64{
+	12.7062, 4.3027, 3.1824, 2.7764, 2.5706, 2.4469, 2.3646, 2.3060, 2.2622, 2.2281,
+	2.2010, 2.1788, 2.1604, 2.1448, 2.1314, 2.1199, 2.1098, 2.1009, 2.0930, 2.0860,
+	2.0796, 2.0739, 2.0687, 2.0639, 2.0595, 2.0555, 2.0518, 2.0484, 2.0452, 2.0423,
+}
+
+// studentT975 returns the 97.5th percentile of Student's t-distribution with
+// df degrees of freedom, which bounds a 95% confidence interval of a mean of
+// df+1 samples. Beyond the table it uses the Cornish-Fisher expansion around
+// the normal distribution's 1.96, accurate to 4 decimals there.
+func studentT975(df int) float64 {
+	if df <= len(studentT975Table) {
+		return studentT975Table[df-1]
+	}
+	const z = 1.959964
+	n := float64(df)
+	z3, z5, z7 := z*z*z, z*z*z*z*z, z*z*z*z*z*z*z
+	return z + (z3+z)/(4*n) + (5*z5+16*z3+3*z)/(96*n*n) + (3*z7+19*z5+17*z3-15*z)/(384*n*n*n)
+}
+
+// updatePrecisionTarget updates stats.TargetRuns with the runs the target
+// precision is expected to take, and returns true when stats is done: it has
+// just reached it, or failed all of its first MinRuns runs and so never will.
+// The interval narrows with the square root of the runs, so reaching a
+// precision p times the target takes about (p/target)² times the runs so far.
+func (runner *Runner) updatePrecisionTarget(stats *CommandStats) bool {
+	if stats.Converged {
+		// Runs that were in flight when it converged
+		stats.TargetRuns = stats.TotalRuns
+		return false
+	}
+
+	minRuns := runner.Options.MinRuns
+	if stats.SuccessfulRuns >= minRuns && stats.SuccessfulRuns >= 2 && stats.Precision <= stats.TargetPrecision {
+		stats.Converged = true
+		stats.TargetRuns = stats.TotalRuns
+		return true
+	}
+	if stats.SuccessfulRuns == 0 && stats.TotalRuns >= minRuns {
+		stats.TargetRuns = stats.TotalRuns
+		return true
+	}
+
+	target := minRuns
+	if stats.Precision > 0 {
+		ratio := stats.Precision / stats.TargetPrecision
+		target = max(target, int(math.Ceil(float64(stats.TotalRuns)*ratio*ratio)))
+	}
+	target = max(target, stats.TotalRuns+1)
+	if maxRuns := runner.Options.MaxRuns; maxRuns > 0 && target > maxRuns {
+		target = maxRuns
+	}
+	stats.TargetRuns = target
+	return false
+}
+
 // Helper functions for incremental statistics calculation
 
-// Constants for median calculation
+// Constants for median calculation. MaxMedianSamples is the default
+// number of samples kept, more are kept for high percentiles.
 const (
 	MaxMedianSamples            = 1000
 	MedianResortInterval        = 250
@@ -469,23 +865,31 @@ const (
 
 // updateMedianSamples updates median samples using reservoir sampling
 func updateMedianSamples(stats *CommandStats, duration time.Duration) {
-	if len(stats.MedianSamples) < MaxMedianSamples {
+	limit := stats.sampleLimit
+	if limit == 0 {
+		limit = MaxMedianSamples
+	}
+	// Large samples are sorted less often, so that sorting costs about the
+	// same per run whatever the sample size
+	resortInterval := max(MedianInitialResortInterval, len(stats.MedianSamples)/20)
+
+	if len(stats.MedianSamples) < limit {
 		// Still building initial sample set
 		stats.MedianSamples = append(stats.MedianSamples, duration)
 
 		// If we just filled the sample set, sort it
-		if len(stats.MedianSamples) == MaxMedianSamples {
+		if len(stats.MedianSamples) == limit {
 			sortMedianSamples(stats)
 		}
 	} else {
 		// Use reservoir sampling to maintain a representative sample
-		if rand.Intn(stats.SuccessfulRuns) < MaxMedianSamples {
+		if rand.Intn(stats.SuccessfulRuns) < limit {
 			// Replace a random sample
-			sampleIndex := rand.Intn(MaxMedianSamples)
+			sampleIndex := rand.Intn(limit)
 			stats.MedianSamples[sampleIndex] = duration
 
 			// Resort the samples periodically
-			if stats.SuccessfulRuns%MedianResortInterval == 0 {
+			if stats.SuccessfulRuns%max(MedianResortInterval, resortInterval) == 0 {
 				sortMedianSamples(stats)
 			}
 		}
@@ -494,7 +898,7 @@ func updateMedianSamples(stats *CommandStats, duration time.Duration) {
 	// Calculate median from samples if we have enough
 	if len(stats.MedianSamples) > 0 {
 		// Sort only periodically to reduce CPU usage
-		if stats.SuccessfulRuns%MedianInitialResortInterval == 0 || stats.SuccessfulRuns <= 5 {
+		if stats.SuccessfulRuns%resortInterval == 0 || stats.SuccessfulRuns <= 5 {
 			sortMedianSamples(stats)
 		}
 
@@ -541,6 +945,7 @@ func updateThroughputStats(stats *CommandStats, result *command.Result) {
 	totalTime := stats.LastEndTime.Sub(stats.FirstStartTime)
 	if totalTime > 0 {
 		stats.Throughput = float64(stats.SuccessfulRuns) / totalTime.Seconds()
+		stats.InputThroughput = stats.Throughput * float64(stats.InputBytes)
 	}
 }
 
@@ -561,7 +966,7 @@ func calculateStdDev(stats *CommandStats) {
 			continue
 		}
 
-		delta := float64(result.Duration.Nanoseconds()) - mean
+		delta := float64(stats.sample(result)) - mean
 		m2 += delta * delta
 		count++
 	}
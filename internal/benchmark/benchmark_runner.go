@@ -2,7 +2,9 @@ package benchmark
 
 import (
 	"context"
+	"io"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/miklosn/cmdperf/internal/command"
@@ -14,7 +16,11 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 
 	runner.emitCommandStarted(index, cmd)
 
+	turns := runner.turns
 	if contextCanceled(ctx) {
+		if turns != nil {
+			turns.finish(index)
+		}
 		return
 	}
 
@@ -26,47 +32,45 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 	workerCtx, workerCancel := context.WithCancel(ctx)
 	defer workerCancel()
 
-	// Determine if we're using duration-based or iteration-based benchmarking
-	var workCh chan int
-	var totalIterations int
+	// Duration mode hands out iterations until the context is cancelled,
+	// and precision mode until the command converges; iteration mode and
+	// MaxRuns stop at a limit that Extend can raise
+	limit := -1
+	switch {
+	case runner.Mode == ModeIterations:
+		limit = runner.Options.Iterations
+	case runner.Mode == ModePrecision && runner.Options.MaxRuns > 0:
+		limit = runner.Options.MaxRuns
+	}
+	queue := newWorkQueue(limit, runner.Options.Parallelism)
+
+	runner.controlMu.Lock()
+	runner.queues[index] = queue
+	runner.cancels[index] = workerCancel
+	runner.controlMu.Unlock()
+	defer func() {
+		runner.controlMu.Lock()
+		runner.cancels[index] = nil
+		runner.controlMu.Unlock()
+	}()
 
-	if runner.Mode == ModeDuration {
-		// For duration-based benchmarking, use a buffered channel with a large capacity
-		workCh = make(chan int, 1000)
-		totalIterations = 0 // Not relevant for duration mode
+	go func() {
+		<-workerCtx.Done()
+		queue.close()
+	}()
 
-		// Start a goroutine to feed the work channel until context is cancelled
-		go func() {
-			defer close(workCh)
-			i := 0
-			for {
-				select {
-				case workCh <- i:
-					i++
-				case <-workerCtx.Done():
-					return
-				}
-			}
-		}()
-	} else {
-		// For iteration-based benchmarking, use the existing approach
-		workCh = make(chan int, runner.Options.Iterations)
-		totalIterations = runner.Options.Iterations
+	resultCh := make(chan *command.Result, runner.Options.Parallelism*2)
+	// Read by the progress ticker while results come in
+	var completedIterations atomic.Int64
 
-		for i := 0; i < runner.Options.Iterations; i++ {
-			select {
-			case workCh <- i:
-			case <-workerCtx.Done():
-				close(workCh)
-				return
-			}
+	// Not relevant for duration and precision mode
+	totalIterations := func() int {
+		if runner.Mode != ModeIterations {
+			return 0
 		}
-		close(workCh)
+		return queue.currentLimit()
 	}
 
-	resultCh := make(chan *command.Result, runner.Options.Parallelism*2)
-	completedIterations := 0
-
 	var workerWg sync.WaitGroup
 	parallelismTokens := make(chan struct{}, runner.Options.Parallelism)
 	for i := 0; i < runner.Options.Parallelism; i++ {
@@ -88,7 +92,13 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 			}
 
 			// Process work items assigned to this worker
-			for range workCh {
+			for {
+				if !runner.waitWhilePaused(workerCtx) {
+					return
+				}
+				if _, ok := queue.next(); !ok {
+					return
+				}
 				if contextCanceled(workerCtx) {
 					return
 				}
@@ -103,9 +113,24 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 					}
 				}
 
+				// Wait for the command's turn, which may come after it has
+				// converged or been stopped
+				if turns != nil {
+					if !turns.acquire(workerCtx, index) {
+						return
+					}
+					if queue.isClosed() {
+						turns.release()
+						return
+					}
+				}
+
 				select {
 				case <-parallelismTokens:
 				case <-workerCtx.Done():
+					if turns != nil {
+						turns.release()
+					}
 					return
 				}
 
@@ -115,6 +140,9 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 				if workerCtx.Err() != nil {
 					result.ContextCancelled = true
 				}
+				if turns != nil {
+					turns.release()
+				}
 
 				select {
 				case parallelismTokens <- struct{}{}:
@@ -132,6 +160,9 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 
 	go func() {
 		workerWg.Wait()
+		if turns != nil {
+			turns.finish(index)
+		}
 		close(resultCh)
 	}()
 
@@ -145,7 +176,7 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 				// Report progress periodically even if no results yet
 				if runner.progressCallback != nil {
 					// Pass the current command index and total iterations to show progress
-					runner.emitCommandProgress(index, cmd, nil, completedIterations, totalIterations, time.Since(startTime))
+					runner.emitCommandProgress(index, nil, int(completedIterations.Load()), totalIterations(), time.Since(startTime))
 					runner.progressCallback(runner.Results, false)
 				}
 			case <-workerCtx.Done():
@@ -168,12 +199,12 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 
 	for result := range resultCh {
 		resultBatch = append(resultBatch, result)
-		completedIterations++
+		completed := int(completedIterations.Add(1))
 
 		shouldProcessBatch := len(resultBatch) >= batchSize ||
-			(runner.Mode == ModeIterations && completedIterations == totalIterations) ||
+			(runner.Mode == ModeIterations && completed == totalIterations()) ||
 			contextCanceled(ctx)
-		if completedIterations <= 5 || time.Since(lastProgressTime) >= 100*time.Millisecond {
+		if completed <= 5 || time.Since(lastProgressTime) >= 100*time.Millisecond {
 			shouldProcessBatch = true
 			lastProgressTime = time.Now()
 		}
@@ -190,7 +221,7 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 		now := time.Now()
 		shouldUpdate := now.Sub(lastEventTime) >= MinUpdateInterval
 		if shouldUpdate || result.Error != nil || result.Duration > time.Second {
-			runner.emitCommandProgress(index, cmd, result, completedIterations, totalIterations, time.Since(startTime))
+			runner.emitCommandProgress(index, result, completed, totalIterations(), time.Since(startTime))
 			lastEventTime = now
 		}
 
@@ -200,5 +231,24 @@ func (runner *Runner) runCommand(ctx context.Context, index int, cmd *command.Co
 		}
 	}
 
-	runner.emitCommandCompleted(index, cmd)
+	// Wait for the runs in flight to be cancelled, so none overlap the next
+	// command in ScheduleSequential
+	for range resultCh {
+	}
+
+	// Executors with processes of their own, such as persistent shells, end
+	// them once the command is done
+	if closer, ok := cmd.Executor.(io.Closer); ok {
+		_ = closer.Close()
+	}
+
+	// The queue can close between batches, such as at Options.MaxRuns
+	if len(resultBatch) > 0 {
+		runner.processBatch(index, resultBatch)
+		if runner.progressCallback != nil {
+			runner.progressCallback(runner.Results, false)
+		}
+	}
+
+	runner.emitCommandCompleted(index, time.Since(startTime))
 }
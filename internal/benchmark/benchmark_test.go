@@ -2,6 +2,8 @@ package benchmark_test
 
 import (
 	"context"
+	"slices"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -36,21 +38,21 @@ func TestRunner(t *testing.T) {
 		t.Fatalf("Failed to create runner: %v", err)
 	}
 
-	progressCalled := false
-	completionCalled := false
+	// Every command's goroutine calls the callback
+	var progressCalled, completionCalled atomic.Bool
 	runner.SetProgressCallback(func(stats []*benchmark.CommandStats, complete bool) {
 		if complete {
-			completionCalled = true
+			completionCalled.Store(true)
 		} else {
-			progressCalled = true
+			progressCalled.Store(true)
 		}
 	})
 
 	runner.Run(context.Background())
-	if !progressCalled {
+	if !progressCalled.Load() {
 		t.Error("Progress callback was never called")
 	}
-	if !completionCalled {
+	if !completionCalled.Load() {
 		t.Error("Completion callback was never called")
 	}
 
@@ -75,3 +77,59 @@ func TestRunner(t *testing.T) {
 		t.Errorf("Expected positive Mean duration, got %v", firstCmdStats.Mean)
 	}
 }
+
+func TestActivitySeries(t *testing.T) {
+	var activity benchmark.Activity
+	now := time.Unix(1000, 500)
+
+	activity.Add(time.Unix(997, 0), 10*time.Millisecond)
+	activity.Add(time.Unix(999, 0), 10*time.Millisecond)
+	activity.Add(time.Unix(999, 1), 30*time.Millisecond)
+	activity.Add(now, time.Second) // current second, not yet reported
+	// Older than the window; must not leak into the bucket it shares
+	activity.Add(time.Unix(999-benchmark.ActivityWindow, 0), time.Hour)
+
+	throughput, latency := activity.Series(now, 3)
+
+	expectedThroughput := []float64{1, 0, 2}
+	expectedLatency := []time.Duration{10 * time.Millisecond, 0, 20 * time.Millisecond}
+	for i := range expectedThroughput {
+		if throughput[i] != expectedThroughput[i] {
+			t.Errorf("throughput[%d] = %v, want %v", i, throughput[i], expectedThroughput[i])
+		}
+		if latency[i] != expectedLatency[i] {
+			t.Errorf("latency[%d] = %v, want %v", i, latency[i], expectedLatency[i])
+		}
+	}
+}
+
+func TestCharts(t *testing.T) {
+	cmd := &command.Command{Raw: "A", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: []time.Duration{time.Millisecond, 2 * time.Millisecond}}
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{Iterations: 200, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+
+	// Charts are drawn from the progress callback while runs are added
+	charted := 0
+	runner.SetProgressCallback(func(stats []*benchmark.CommandStats, complete bool) {
+		samples, _, _ := stats[0].Charts(time.Now(), 10)
+		if len(samples) > 0 {
+			samples[0] = -1
+			charted++
+		}
+	})
+	runner.Run(context.Background())
+
+	if charted == 0 {
+		t.Fatal("No samples charted")
+	}
+	samples, throughput, latency := runner.Results[0].Charts(time.Now(), 10)
+	if len(samples) != 200 || len(throughput) != 10 || len(latency) != 10 {
+		t.Errorf("Charts = %d samples, %d and %d seconds, want 200 samples and 10 seconds", len(samples), len(throughput), len(latency))
+	}
+	if slices.Contains(runner.Results[0].MedianSamples, -1) {
+		t.Error("Charts returned the samples themselves, not a copy")
+	}
+}
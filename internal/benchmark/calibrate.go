@@ -1,6 +1,14 @@
 package benchmark
 
-import "time"
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
 
 // TimerOverhead measures the typical cost of a single time.Since call.
 // Useful context when interpreting sub-microsecond durations.
@@ -13,3 +21,96 @@ func TimerOverhead() time.Duration {
 	}
 	return total / samples
 }
+
+// ShellCalibrationRuns is how many times CalibrateShell runs the empty
+// command, after a few untimed runs to warm up the page cache
+const ShellCalibrationRuns = 30
+
+// ShellOverhead is the cost of starting a shell for every run, which
+// dominates commands that take less than a millisecond or so
+type ShellOverhead struct {
+	// Shell is the shell and its options, such as "/bin/sh -c"
+	Shell string
+
+	// Mean and StdDev of running an empty command through the shell
+	Mean, StdDev time.Duration
+	Runs         int
+
+	// Subtracted is set when Mean was subtracted from every run
+	Subtracted bool
+}
+
+// noise is how far apart two measurements of the shell alone may be: twice
+// the standard deviation, but at least a tenth of the mean, since the shell
+// also starts a little slower or faster between the calibration and a
+// benchmark
+func (o *ShellOverhead) noise() time.Duration {
+	return max(2*o.StdDev, o.Mean/10)
+}
+
+// sample returns the duration of result that the statistics of s record,
+// less the shell overhead when it is Subtracted. The Result keeps the
+// duration as measured.
+func (s *CommandStats) sample(result *command.Result) time.Duration {
+	if o := s.ShellOverhead; o != nil && o.Subtracted {
+		return max(result.Duration-o.Mean, 0)
+	}
+	return result.Duration
+}
+
+// CalibrateShell measures the overhead of shell by running an empty command
+// through it with options, as a command's runs would. It fails if the empty
+// command does.
+func CalibrateShell(ctx context.Context, shell string, options []string, timeout time.Duration) (*ShellOverhead, error) {
+	cmd := command.NewShell("", shell, options)
+	cmd.Timeout = timeout
+
+	const warmup = 3
+	samples := make([]float64, 0, ShellCalibrationRuns)
+	for i := 0; i < warmup+ShellCalibrationRuns; i++ {
+		result := cmd.Execute(ctx)
+		duration, err := result.Duration, result.Error
+		command.ReleaseResult(result)
+		if err != nil {
+			return nil, fmt.Errorf("benchmark: calibrating %s: %w", shell, err)
+		}
+		if i >= warmup {
+			samples = append(samples, float64(duration))
+		}
+	}
+
+	return &ShellOverhead{
+		Shell:  strings.Join(append([]string{shell}, options...), " "),
+		Mean:   time.Duration(meanFloat(samples)),
+		StdDev: time.Duration(math.Sqrt(varianceFloat(samples))),
+		Runs:   len(samples),
+	}, nil
+}
+
+// usesShell reports whether cmd runs through a shell, rather than directly
+// or with an Executor
+func usesShell(cmd *command.Command) bool {
+	return cmd.Executor == nil && !cmd.DirectExec && cmd.Shell != ""
+}
+
+// calibrateShells measures the overhead of every shell the commands use,
+// once per shell and options. A shell that fails to calibrate is left
+// without, since the benchmark will report its failures anyway.
+func (runner *Runner) calibrateShells(ctx context.Context) {
+	calibrated := make(map[string]*ShellOverhead)
+	for i, cmd := range runner.Commands {
+		if !usesShell(cmd) {
+			continue
+		}
+		key := strings.Join(append([]string{cmd.Shell}, cmd.ShellOptions...), "\x00")
+		overhead, ok := calibrated[key]
+		if !ok {
+			overhead, _ = CalibrateShell(ctx, cmd.Shell, cmd.ShellOptions, cmd.Timeout)
+			if overhead != nil {
+				overhead.Subtracted = runner.Options.SubtractShellOverhead
+			}
+			calibrated[key] = overhead
+		}
+		runner.Results[i].ShellOverhead = overhead
+	}
+}
@@ -0,0 +1,94 @@
+package benchmark_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// slowShell writes a shell that takes 20ms longer to start than /bin/sh
+func slowShell(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "slowsh")
+	script := "#!/bin/sh\nsleep 0.02\nexec /bin/sh \"$@\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("Failed to write shell: %v", err)
+	}
+	return path
+}
+
+func TestCalibrateShell(t *testing.T) {
+	overhead, err := benchmark.CalibrateShell(context.Background(), slowShell(t), []string{"-c"}, 5*time.Second)
+	if err != nil {
+		t.Fatalf("CalibrateShell failed: %v", err)
+	}
+	if overhead.Runs != benchmark.ShellCalibrationRuns {
+		t.Errorf("Runs = %d, want %d", overhead.Runs, benchmark.ShellCalibrationRuns)
+	}
+	if overhead.Mean < 20*time.Millisecond || overhead.Mean > 100*time.Millisecond {
+		t.Errorf("Mean = %s, want about 20ms", overhead.Mean)
+	}
+
+	if _, err := benchmark.CalibrateShell(context.Background(), "/nonexistent/sh", []string{"-c"}, time.Second); err == nil {
+		t.Error("CalibrateShell succeeded with a missing shell")
+	}
+}
+
+func TestSubtractShellOverhead(t *testing.T) {
+	shell := slowShell(t)
+	commands := []*command.Command{
+		{Raw: "sleep 0.03", Shell: shell, ShellOptions: []string{"-c"}, Parallelism: 1, Timeout: 5 * time.Second},
+		{Raw: "true", Shell: shell, ShellOptions: []string{"-c"}, Parallelism: 1, Timeout: 5 * time.Second},
+	}
+	runner, err := benchmark.NewRunner(commands, benchmark.Options{Iterations: 10, Parallelism: 1, SubtractShellOverhead: true})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	sleep, empty := runner.Results[0], runner.Results[1]
+	if sleep.ShellOverhead == nil || !sleep.ShellOverhead.Subtracted {
+		t.Fatalf("ShellOverhead = %+v, want it subtracted", sleep.ShellOverhead)
+	}
+	if empty.ShellOverhead != sleep.ShellOverhead {
+		t.Error("The shell was calibrated twice")
+	}
+	// Without the 20ms of the shell, sleep 0.03 takes about 30ms
+	if sleep.Mean < 25*time.Millisecond || sleep.Mean > 45*time.Millisecond {
+		t.Errorf("Mean = %s, want about 30ms", sleep.Mean)
+	}
+	// The results keep their durations as measured, with the shell
+	for _, result := range sleep.RecentResults {
+		if result.Duration < sleep.Min+sleep.ShellOverhead.Mean/2 {
+			t.Errorf("Result duration %s, want the measured duration above %s", result.Duration, sleep.Min+sleep.ShellOverhead.Mean)
+		}
+	}
+	if sleep.MostlyShellOverhead {
+		t.Error("sleep 0.03 flagged as mostly shell overhead")
+	}
+	if !empty.MostlyShellOverhead {
+		t.Errorf("true (mean %s) not flagged as mostly shell overhead of %s ± %s",
+			empty.Mean, empty.ShellOverhead.Mean, empty.ShellOverhead.StdDev)
+	}
+}
+
+func TestShellOverheadWithoutShell(t *testing.T) {
+	cmd := &command.Command{Raw: "A", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: []time.Duration{time.Millisecond}}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{Iterations: 3, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	if overhead := runner.Results[0].ShellOverhead; overhead != nil {
+		t.Errorf("ShellOverhead = %+v, want none for an executor", overhead)
+	}
+}
@@ -0,0 +1,202 @@
+package benchmark
+
+import (
+	"context"
+	"time"
+)
+
+// Pause stops workers from starting new runs until Resume is called. Runs
+// already in flight complete normally. In duration and precision mode the time
+// spent paused is added to the deadline.
+func (r *Runner) Pause() {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+
+	if r.resumeCh != nil {
+		return
+	}
+	r.resumeCh = make(chan struct{})
+	r.paused.Store(true)
+	r.pausedAt = time.Now()
+	r.notifyControl()
+}
+
+// Resume lets paused workers continue
+func (r *Runner) Resume() {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+
+	if r.resumeCh == nil {
+		return
+	}
+	close(r.resumeCh)
+	r.resumeCh = nil
+	r.paused.Store(false)
+	if !r.deadline.IsZero() {
+		r.deadline = r.deadline.Add(time.Since(r.pausedAt))
+	}
+	r.notifyControl()
+}
+
+// Paused reports whether the benchmark is paused
+func (r *Runner) Paused() bool {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+	return r.resumeCh != nil
+}
+
+// CanExtend reports whether Extend has a limit to raise. In precision mode
+// without MaxRuns and MaxTime, commands already run until they converge.
+func (r *Runner) CanExtend() bool {
+	return r.Mode != ModePrecision || r.Options.MaxRuns > 0 || r.Options.MaxTime > 0
+}
+
+// Extend lengthens the benchmark by its original size: another
+// Options.Iterations runs for every command still running, or another
+// Options.Duration of time, for the command running with
+// ScheduleSequential. In precision mode it raises Options.MaxRuns and
+// Options.MaxTime by their original values, where set.
+func (r *Runner) Extend() {
+	if r.Mode == ModeDuration {
+		r.controlMu.Lock()
+		if !r.deadline.IsZero() {
+			r.deadline = r.deadline.Add(r.Options.Duration)
+			r.notifyControl()
+		}
+		r.controlMu.Unlock()
+		return
+	}
+
+	extendBy := r.Options.Iterations
+	if r.Mode == ModePrecision {
+		// Raise the limits that keep a command that doesn't settle from
+		// running forever
+		r.controlMu.Lock()
+		if !r.deadline.IsZero() {
+			r.deadline = r.deadline.Add(r.Options.MaxTime)
+			r.notifyControl()
+		}
+		r.controlMu.Unlock()
+		extendBy = r.Options.MaxRuns
+	}
+
+	r.controlMu.Lock()
+	queues := r.queues
+	r.controlMu.Unlock()
+
+	for i, q := range queues {
+		if q == nil {
+			continue
+		}
+		if limit, ok := q.extend(extendBy); ok && r.Mode == ModeIterations {
+			r.statsMutex.Lock()
+			r.Results[i].TargetRuns = limit
+			r.statsMutex.Unlock()
+		}
+	}
+}
+
+// Skip stops the command at index if it is still running. Results collected
+// so far are kept.
+func (r *Runner) Skip(index int) {
+	r.controlMu.Lock()
+	var cancel context.CancelFunc
+	if index >= 0 && index < len(r.cancels) {
+		cancel = r.cancels[index]
+	}
+	r.controlMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	r.statsMutex.Lock()
+	r.Results[index].Skipped = true
+	r.statsMutex.Unlock()
+
+	cancel()
+}
+
+// Stop ends the benchmark early. Unlike cancelling the context passed to Run,
+// it is a normal way to finish: results collected so far are kept.
+func (r *Runner) Stop() {
+	r.controlMu.Lock()
+	stop := r.stop
+	r.stopped = true
+	r.controlMu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+}
+
+// Stopped reports whether Stop was called
+func (r *Runner) Stopped() bool {
+	r.controlMu.Lock()
+	defer r.controlMu.Unlock()
+	return r.stopped
+}
+
+// waitWhilePaused blocks while the benchmark is paused, returning false if
+// ctx is done first
+func (r *Runner) waitWhilePaused(ctx context.Context) bool {
+	// Keep the common, unpaused path off the mutex
+	if !r.paused.Load() {
+		return true
+	}
+
+	r.controlMu.Lock()
+	resumeCh := r.resumeCh
+	r.controlMu.Unlock()
+
+	if resumeCh == nil {
+		return true
+	}
+	select {
+	case <-resumeCh:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// notifyControl wakes enforceDeadline; must be called with controlMu held
+func (r *Runner) notifyControl() {
+	select {
+	case r.controlCh <- struct{}{}:
+	default:
+	}
+}
+
+// enforceDeadline cancels the benchmark once the (extendable, pausable)
+// duration has elapsed
+func (r *Runner) enforceDeadline(ctx context.Context, cancel context.CancelFunc) {
+	for {
+		r.controlMu.Lock()
+		var timerCh <-chan time.Time
+		var timer *time.Timer
+		if r.resumeCh == nil {
+			remaining := time.Until(r.deadline)
+			if remaining <= 0 {
+				r.controlMu.Unlock()
+				cancel()
+				return
+			}
+			timer = time.NewTimer(remaining)
+			timerCh = timer.C
+		}
+		r.controlMu.Unlock()
+
+		select {
+		case <-timerCh:
+		case <-r.controlCh:
+		case <-ctx.Done():
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package benchmark_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func newSleepRunner(t *testing.T, options benchmark.Options, raws ...string) *benchmark.Runner {
+	t.Helper()
+
+	commands := make([]*command.Command, len(raws))
+	for i, raw := range raws {
+		commands[i] = &command.Command{
+			Raw:          raw,
+			Shell:        "/bin/sh",
+			ShellOptions: []string{"-c"},
+			Parallelism:  options.Parallelism,
+			Timeout:      5 * time.Second,
+		}
+	}
+
+	runner, err := benchmark.NewRunner(commands, options)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	return runner
+}
+
+func TestRunnerExtend(t *testing.T) {
+	runner := newSleepRunner(t, benchmark.Options{Iterations: 4, Parallelism: 1}, "sleep 0.05")
+
+	extended := false
+	runner.SetProgressCallback(func(stats []*benchmark.CommandStats, complete bool) {
+		if !extended && stats[0] != nil && stats[0].TotalRuns >= 1 {
+			extended = true
+			go runner.Extend()
+		}
+	})
+
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if stats.TotalRuns != 8 {
+		t.Errorf("TotalRuns = %d, want 8 after extending", stats.TotalRuns)
+	}
+	if stats.TargetRuns != 8 {
+		t.Errorf("TargetRuns = %d, want 8 after extending", stats.TargetRuns)
+	}
+}
+
+func TestRunnerCanExtend(t *testing.T) {
+	for name, test := range map[string]struct {
+		options benchmark.Options
+		want    bool
+	}{
+		"runs":                {benchmark.Options{Iterations: 4}, true},
+		"duration":            {benchmark.Options{Duration: time.Second}, true},
+		"precision":           {benchmark.Options{TargetPrecision: 0.01}, false},
+		"precision, max runs": {benchmark.Options{TargetPrecision: 0.01, MaxRuns: 100}, true},
+		"precision, max time": {benchmark.Options{TargetPrecision: 0.01, MaxTime: time.Minute}, true},
+	} {
+		test.options.Parallelism = 1
+		if got := newSleepRunner(t, test.options, "true").CanExtend(); got != test.want {
+			t.Errorf("%s: CanExtend() = %t, want %t", name, got, test.want)
+		}
+	}
+}
+
+func TestRunnerSkipAndStop(t *testing.T) {
+	runner := newSleepRunner(t, benchmark.Options{Iterations: 1000, Parallelism: 1}, "sleep 0.01", "sleep 0.01")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		runner.Skip(0)
+		time.Sleep(100 * time.Millisecond)
+		runner.Stop()
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		runner.Run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Run did not return after Skip and Stop")
+	}
+
+	if !runner.Results[0].Skipped {
+		t.Error("First command not marked as skipped")
+	}
+	if runner.Results[1].Skipped {
+		t.Error("Second command marked as skipped")
+	}
+	if !runner.Stopped() {
+		t.Error("Stopped() = false after Stop")
+	}
+	if runner.Results[0].TotalRuns >= runner.Results[1].TotalRuns {
+		t.Errorf("Skipped command ran %d times, not fewer than the other's %d",
+			runner.Results[0].TotalRuns, runner.Results[1].TotalRuns)
+	}
+}
+
+func TestRunnerPauseExtendsDuration(t *testing.T) {
+	runner := newSleepRunner(t, benchmark.Options{Duration: 200 * time.Millisecond, Parallelism: 1}, "true")
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		runner.Pause()
+		time.Sleep(300 * time.Millisecond)
+		runner.Resume()
+	}()
+
+	start := time.Now()
+	runner.Run(context.Background())
+	elapsed := time.Since(start)
+
+	if elapsed < 450*time.Millisecond {
+		t.Errorf("Run returned after %v, expected the pause to push the deadline back", elapsed)
+	}
+}
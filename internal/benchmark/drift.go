@@ -0,0 +1,248 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// TimelineBlocks is the most blocks a command's timeline holds
+	TimelineBlocks = 256
+
+	// MinDriftRuns is the fewest runs drift is analyzed for
+	MinDriftRuns = 10
+
+	// driftZ is the Mann-Kendall z-score a trend must exceed, p < 0.01
+	driftZ = 2.576
+
+	// MinDrift is the smallest relative change reported as drift; slower
+	// trends are real but too small to matter
+	MinDrift = 0.05
+
+	// driftSegments is the most points trends are tested on. Timing noise
+	// comes in bursts, which the test would take for trends between
+	// consecutive runs, so runs are averaged into segments first.
+	driftSegments = 32
+)
+
+// timeline keeps run durations in the order they completed, as the means of
+// blocks of consecutive runs. When it fills up, neighbouring blocks are
+// merged, so it covers the whole benchmark in constant memory.
+type timeline struct {
+	blocks    []float64
+	blockSize int
+
+	partialSum   float64
+	partialCount int
+}
+
+func (t *timeline) add(d time.Duration) {
+	if t.blockSize == 0 {
+		t.blockSize = 1
+		t.blocks = make([]float64, 0, TimelineBlocks)
+	}
+
+	t.partialSum += float64(d)
+	t.partialCount++
+	if t.partialCount < t.blockSize {
+		return
+	}
+
+	t.blocks = append(t.blocks, t.partialSum/float64(t.partialCount))
+	t.partialSum, t.partialCount = 0, 0
+
+	if len(t.blocks) == TimelineBlocks {
+		for i := 0; i < TimelineBlocks/2; i++ {
+			t.blocks[i] = (t.blocks[2*i] + t.blocks[2*i+1]) / 2
+		}
+		t.blocks = t.blocks[:TimelineBlocks/2]
+		t.blockSize *= 2
+	}
+}
+
+// Drift describes how latency changed over the course of a benchmark, such
+// as commands that warm up caches, or services that slow down as they leak
+// memory or the machine throttles
+type Drift struct {
+	// Trend is the change of latency from the first run to the last by
+	// Sen's slope, relative to the median, such as 0.1 when the last runs
+	// took 10% of the median longer than the first. Trending is set when the
+	// Mann-Kendall test finds it significant.
+	Trend    float64
+	Trending bool
+
+	// SteadyFrom is the first run of the steady state, after the runs that
+	// were still warming up (or cooling down): 1 without a warm-up, 0 when
+	// latency is still drifting at the end. Warmup is how much the runs
+	// before it differed from the rest, such as 0.5 for 50% slower.
+	SteadyFrom int
+	Warmup     float64
+
+	// EndTrend and DriftingAtEnd are Trend and Trending for the last half of
+	// the runs. Drift at the end means more runs won't give a stable result.
+	EndTrend      float64
+	DriftingAtEnd bool
+}
+
+// String summarizes the drift, such as "steady from run 31, the runs before
+// it +35%" or "still drifting, +12% over the last half of the runs"
+func (d *Drift) String() string {
+	switch {
+	case d.DriftingAtEnd:
+		return fmt.Sprintf("still drifting, %+.0f%% over the last half of the runs", d.EndTrend*100)
+	case d.SteadyFrom > 1:
+		return fmt.Sprintf("steady from run %d, the runs before it %+.0f%%", d.SteadyFrom, d.Warmup*100)
+	case d.Trending:
+		return fmt.Sprintf("%+.0f%% over the run", d.Trend*100)
+	default:
+		return "none"
+	}
+}
+
+// analyzeDrift analyzes the timeline, or returns nil with too few runs
+func analyzeDrift(t *timeline) *Drift {
+	n := len(t.blocks)
+	if n*t.blockSize < MinDriftRuns || n < MinDriftRuns {
+		return nil
+	}
+
+	segments, runsPerSegment := segment(t.blocks, driftSegments)
+	runsPerSegment *= float64(t.blockSize)
+	m := len(segments)
+
+	drift := &Drift{}
+	drift.Trend, drift.Trending = trend(segments)
+	drift.EndTrend, drift.DriftingAtEnd = trend(segments[m/2:])
+
+	// The steady state starts where the marginal standard error rule (MSER)
+	// truncates the warm-up, when the warm-up differs enough to matter
+	drift.SteadyFrom = 1
+	if start := mser(segments); start > 0 {
+		warm, steady := meanFloat(segments[:start]), meanFloat(segments[start:])
+		if steady > 0 && math.Abs(warm-steady)/steady >= MinDrift && differs(segments, start) {
+			drift.SteadyFrom = int(float64(start)*runsPerSegment) + 1
+			drift.Warmup = (warm - steady) / steady
+		}
+	}
+	if drift.DriftingAtEnd {
+		drift.SteadyFrom = 0
+	}
+	return drift
+}
+
+// differs reports whether the mean of series[:start] differs significantly
+// from that of series[start:], measured against the spread of the latter
+func differs(series []float64, start int) bool {
+	warm, steady := series[:start], series[start:]
+	if len(steady) < 2 {
+		return false
+	}
+	mean := meanFloat(steady)
+	se := math.Sqrt(varianceFloat(steady) * (1/float64(len(warm)) + 1/float64(len(steady))))
+	if se == 0 {
+		return meanFloat(warm) != mean
+	}
+	return math.Abs(meanFloat(warm)-mean)/se > driftZ
+}
+
+// mser returns the number of leading points to drop so the rest has the
+// smallest marginal standard error, looking no further than halfway
+func mser(series []float64) int {
+	best, bestErr := 0, math.Inf(1)
+	for d := 0; d <= len(series)/2; d++ {
+		rest := series[d:]
+		mean := meanFloat(rest)
+		var ss float64
+		for _, x := range rest {
+			ss += (x - mean) * (x - mean)
+		}
+		n := float64(len(rest))
+		if err := ss / (n * n); err < bestErr {
+			best, bestErr = d, err
+		}
+	}
+	return best
+}
+
+func meanFloat(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// segment summarizes blocks as at most n segments of about equal size, by
+// their medians so that a few spikes don't look like a trend, and returns
+// them with the number of blocks per segment
+func segment(blocks []float64, n int) ([]float64, float64) {
+	if len(blocks) <= n {
+		return blocks, 1
+	}
+	size := float64(len(blocks)) / float64(n)
+	segments := make([]float64, n)
+	for i := range segments {
+		from, to := int(float64(i)*size), int(float64(i+1)*size)
+		sorted := append([]float64(nil), blocks[from:to]...)
+		sort.Float64s(sorted)
+		if len(sorted)%2 == 1 {
+			segments[i] = sorted[len(sorted)/2]
+		} else {
+			segments[i] = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+		}
+	}
+	return segments, size
+}
+
+// trend returns the relative change over series by Sen's slope, and whether
+// the Mann-Kendall test finds a trend that is both significant and at least
+// MinDrift
+func trend(series []float64) (float64, bool) {
+	n := len(series)
+	if n < 3 {
+		return 0, false
+	}
+
+	var s float64
+	slopes := make([]float64, 0, n*(n-1)/2)
+	for i := 0; i < n-1; i++ {
+		for j := i + 1; j < n; j++ {
+			d := series[j] - series[i]
+			switch {
+			case d > 0:
+				s++
+			case d < 0:
+				s--
+			}
+			slopes = append(slopes, d/float64(j-i))
+		}
+	}
+
+	sort.Float64s(slopes)
+	slope := slopes[len(slopes)/2]
+	if len(slopes)%2 == 0 {
+		slope = (slopes[len(slopes)/2-1] + slope) / 2
+	}
+
+	sorted := append([]float64(nil), series...)
+	sort.Float64s(sorted)
+	median := sorted[n/2]
+	if median <= 0 {
+		return 0, false
+	}
+	relative := slope * float64(n-1) / median
+
+	// Normal approximation of S, with a continuity correction
+	variance := float64(n*(n-1)*(2*n+5)) / 18
+	var z float64
+	switch {
+	case s > 0:
+		z = (s - 1) / math.Sqrt(variance)
+	case s < 0:
+		z = (s + 1) / math.Sqrt(variance)
+	}
+
+	return relative, math.Abs(z) > driftZ && math.Abs(relative) >= MinDrift
+}
@@ -0,0 +1,105 @@
+package benchmark_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// jitter is deterministic noise of up to ±0.3ms
+func jitter(i int) time.Duration {
+	return time.Duration((i*7919)%13-6) * 50 * time.Microsecond
+}
+
+func runSequence(t *testing.T, durations []time.Duration) *benchmark.CommandStats {
+	t.Helper()
+
+	cmd := &command.Command{Raw: "sequence", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: durations}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{
+		Iterations:  len(durations),
+		Parallelism: 1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+	return runner.Results[0]
+}
+
+func TestDriftWarmup(t *testing.T) {
+	// 30 runs speeding up from 30ms to 10ms, then steady
+	durations := make([]time.Duration, 200)
+	for i := range durations {
+		durations[i] = 10*time.Millisecond + jitter(i)
+		if i < 30 {
+			durations[i] += time.Duration(30-i) * 2 * time.Millisecond / 3
+		}
+	}
+
+	drift := runSequence(t, durations).Drift
+	if drift == nil {
+		t.Fatal("Drift = nil")
+	}
+	if drift.Warmup < 0.3 {
+		t.Errorf("Warmup = %.3f, want the first runs much slower", drift.Warmup)
+	}
+	if drift.SteadyFrom < 10 || drift.SteadyFrom > 60 {
+		t.Errorf("SteadyFrom = %d, want around run 30", drift.SteadyFrom)
+	}
+	if drift.DriftingAtEnd {
+		t.Errorf("DriftingAtEnd = true (%.3f), want false", drift.EndTrend)
+	}
+}
+
+func TestDriftStillDrifting(t *testing.T) {
+	// Slowing down from 10ms to 15ms over 600 runs, more than the timeline
+	// holds unmerged. That is 40% of the 12.5ms median.
+	durations := make([]time.Duration, 600)
+	for i := range durations {
+		durations[i] = 10*time.Millisecond + time.Duration(i)*5*time.Millisecond/600 + jitter(i)
+	}
+
+	drift := runSequence(t, durations).Drift
+	if drift == nil {
+		t.Fatal("Drift = nil")
+	}
+	if !drift.Trending || drift.Trend < 0.35 || drift.Trend > 0.45 {
+		t.Errorf("Trend = %.3f, Trending = %v, want about +0.4", drift.Trend, drift.Trending)
+	}
+	if !drift.DriftingAtEnd || drift.EndTrend <= 0 {
+		t.Errorf("EndTrend = %.3f, DriftingAtEnd = %v, want an upward trend", drift.EndTrend, drift.DriftingAtEnd)
+	}
+	if drift.SteadyFrom != 0 {
+		t.Errorf("SteadyFrom = %d, want 0 for no steady state", drift.SteadyFrom)
+	}
+}
+
+func TestDriftSteady(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		durations[i] = 10*time.Millisecond + jitter(i)
+	}
+
+	drift := runSequence(t, durations).Drift
+	if drift == nil {
+		t.Fatal("Drift = nil")
+	}
+	if drift.Trending || drift.DriftingAtEnd {
+		t.Errorf("Drift = %+v, want no trend", *drift)
+	}
+	if drift.SteadyFrom != 1 {
+		t.Errorf("SteadyFrom = %d, want 1", drift.SteadyFrom)
+	}
+}
+
+func TestDriftTooFewRuns(t *testing.T) {
+	durations := []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}
+	if drift := runSequence(t, durations).Drift; drift != nil {
+		t.Errorf("Drift = %+v, want nil for %d runs", *drift, len(durations))
+	}
+}
@@ -0,0 +1,172 @@
+package benchmark
+
+import (
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// Event is a benchmark event, delivered to the handler set with
+// Runner.SetEventHandler. It is one of *BenchmarkStarted, *CommandStarted,
+// *CommandProgress, *CommandCompleted or *BenchmarkCompleted; no other types
+// implement it. Events are values: they share no state with the runner and
+// may be kept or passed to other goroutines.
+//
+// Every event marshals to JSON with snake_case keys and durations in
+// nanoseconds, suffixed _ns.
+type Event interface {
+	// EventType returns the event's name, such as "command_progress"
+	EventType() string
+
+	isEvent()
+}
+
+// BenchmarkStarted is emitted once, before any command runs
+type BenchmarkStarted struct {
+	Time        time.Time     `json:"time"`
+	Commands    []string      `json:"commands"`
+	Iterations  int           `json:"iterations,omitempty"`
+	Duration    time.Duration `json:"duration_ns,omitempty"`
+	Parallelism int           `json:"parallelism"`
+	Rate        float64       `json:"rate,omitempty"`
+
+	// Schedule is Options.Schedule, and Seed the seed of ScheduleRandom
+	Schedule string `json:"schedule"`
+	Seed     int64  `json:"seed,omitempty"`
+}
+
+// CommandStarted is emitted when a command's workers start
+type CommandStarted struct {
+	Time    time.Time `json:"time"`
+	Index   int       `json:"command_index"`
+	Command string    `json:"command"`
+}
+
+// CommandProgress is emitted periodically while a command runs, and after
+// runs that failed or took longer than a second
+type CommandProgress struct {
+	Time  time.Time `json:"time"`
+	Index int       `json:"command_index"`
+
+	// Completed runs, and the runs expected in iteration mode
+	Completed int `json:"completed"`
+	Total     int `json:"total,omitempty"`
+
+	// Elapsed time since the command started, and its completion between 0
+	// and 1
+	Elapsed  time.Duration `json:"elapsed_ns"`
+	Progress float64       `json:"progress"`
+
+	Stats Snapshot `json:"stats"`
+
+	// Result is the run that triggered the event, nil for periodic updates
+	Result *RunResult `json:"result,omitempty"`
+}
+
+// CommandCompleted is emitted when a command has finished all its runs, or
+// was skipped or cancelled
+type CommandCompleted struct {
+	Time    time.Time     `json:"time"`
+	Index   int           `json:"command_index"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+	Stats   Snapshot      `json:"stats"`
+}
+
+// BenchmarkCompleted is emitted once, after the final statistics are
+// calculated
+type BenchmarkCompleted struct {
+	Time    time.Time     `json:"time"`
+	Elapsed time.Duration `json:"elapsed_ns"`
+
+	// Stopped is set when Runner.Stop ended the benchmark early, and
+	// Interrupted when its context was cancelled
+	Stopped     bool `json:"stopped,omitempty"`
+	Interrupted bool `json:"interrupted,omitempty"`
+
+	Results []Snapshot `json:"results"`
+}
+
+func (*BenchmarkStarted) EventType() string   { return "benchmark_started" }
+func (*CommandStarted) EventType() string     { return "command_started" }
+func (*CommandProgress) EventType() string    { return "command_progress" }
+func (*CommandCompleted) EventType() string   { return "command_completed" }
+func (*BenchmarkCompleted) EventType() string { return "benchmark_completed" }
+
+func (*BenchmarkStarted) isEvent()   {}
+func (*CommandStarted) isEvent()     {}
+func (*CommandProgress) isEvent()    {}
+func (*CommandCompleted) isEvent()   {}
+func (*BenchmarkCompleted) isEvent() {}
+
+// Snapshot is a copy of a command's statistics at one point in time
+type Snapshot struct {
+	Command        string        `json:"command"`
+	TotalRuns      int           `json:"total_runs"`
+	SuccessfulRuns int           `json:"successful_runs"`
+	ErrorCount     int           `json:"error_count"`
+	Min            time.Duration `json:"min_ns"`
+	Max            time.Duration `json:"max_ns"`
+	Mean           time.Duration `json:"mean_ns"`
+	Median         time.Duration `json:"median_ns"`
+	StdDev         time.Duration `json:"stddev_ns"`
+	Throughput     float64       `json:"throughput_per_sec"`
+	Skipped        bool          `json:"skipped,omitempty"`
+
+	// Outcomes counts the runs by how they ended
+	Outcomes Outcomes `json:"outcomes"`
+
+	// Percentiles are only calculated once the benchmark completes
+	P95 time.Duration `json:"p95_ns,omitempty"`
+	P99 time.Duration `json:"p99_ns,omitempty"`
+}
+
+// Snapshot copies the statistics the caller must not modify concurrently
+func (s *CommandStats) Snapshot() Snapshot {
+	snapshot := Snapshot{
+		TotalRuns:      s.TotalRuns,
+		SuccessfulRuns: s.SuccessfulRuns,
+		ErrorCount:     s.ErrorCount,
+		Min:            s.Min,
+		Max:            s.Max,
+		Mean:           s.Mean,
+		Median:         s.Median,
+		StdDev:         s.StdDev,
+		Throughput:     s.Throughput,
+		Skipped:        s.Skipped,
+		Outcomes:       s.Outcomes.clone(),
+		P95:            s.P95,
+		P99:            s.P99,
+	}
+	if s.Command != nil {
+		snapshot.Command = s.Command.Raw
+	}
+	return snapshot
+}
+
+// RunResult describes a single run. Unlike command.Result it is not pooled,
+// so it stays valid after the event is handled.
+type RunResult struct {
+	Duration time.Duration `json:"duration_ns"`
+	ExitCode int           `json:"exit_code"`
+	Error    string        `json:"error,omitempty"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+
+	// Outcome is how the run ended, such as "unexpected_exit", and Signal
+	// the signal that killed it
+	Outcome string `json:"outcome"`
+	Signal  string `json:"signal,omitempty"`
+}
+
+func newRunResult(result *command.Result) *RunResult {
+	run := &RunResult{
+		Duration: result.Duration,
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+		Outcome:  result.Outcome().String(),
+		Signal:   result.Signal,
+	}
+	if result.Error != nil {
+		run.Error = result.Error.Error()
+	}
+	return run
+}
@@ -0,0 +1,58 @@
+package benchmark_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+func TestRunnerEvents(t *testing.T) {
+	runner := newSleepRunner(t, benchmark.Options{Iterations: 3, Parallelism: 1}, "true", "exit 1")
+
+	var mu sync.Mutex
+	var events []benchmark.Event
+	runner.SetEventHandler(func(event benchmark.Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	})
+	runner.Run(context.Background())
+
+	if len(events) < 4 {
+		t.Fatalf("Got %d events, want at least 4", len(events))
+	}
+
+	started, ok := events[0].(*benchmark.BenchmarkStarted)
+	if !ok {
+		t.Fatalf("First event = %T, want *BenchmarkStarted", events[0])
+	}
+	if len(started.Commands) != 2 || started.Iterations != 3 {
+		t.Errorf("BenchmarkStarted = %+v", started)
+	}
+
+	completed, ok := events[len(events)-1].(*benchmark.BenchmarkCompleted)
+	if !ok {
+		t.Fatalf("Last event = %T, want *BenchmarkCompleted", events[len(events)-1])
+	}
+	if len(completed.Results) != 2 || completed.Results[0].TotalRuns != 3 || completed.Results[1].ErrorCount != 3 {
+		t.Errorf("BenchmarkCompleted results = %+v", completed.Results)
+	}
+
+	counts := make(map[string]int)
+	for _, event := range events {
+		counts[event.EventType()]++
+		if progress, ok := event.(*benchmark.CommandProgress); ok && progress.Result != nil && progress.Index == 1 {
+			if progress.Result.ExitCode != 1 || progress.Result.Error == "" {
+				t.Errorf("Failed run reported as %+v", progress.Result)
+			}
+		}
+	}
+	if counts["command_started"] != 2 || counts["command_completed"] != 2 {
+		t.Errorf("Event counts = %v, want 2 command_started and 2 command_completed", counts)
+	}
+	if counts["command_progress"] == 0 {
+		t.Error("No command_progress events for the failing command")
+	}
+}
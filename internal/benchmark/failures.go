@@ -0,0 +1,42 @@
+package benchmark
+
+import (
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// MaxFailedRuns is how many failed runs of a command with captured output
+// CommandStats.FailedRuns keeps
+const MaxFailedRuns = 3
+
+// FailedRun is the captured output of a failed run, kept for debugging
+type FailedRun struct {
+	// Run is the number of the run, counting from 1 in the order the runs
+	// completed
+	Run      int
+	ExitCode int
+	Error    string
+
+	// ValidationFailed is set when the run exited 0 with an unexpected
+	// stdout
+	ValidationFailed bool
+
+	// The first command.CaptureLimit bytes of the output
+	Stdout, Stderr string
+}
+
+// addFailure keeps a failed run's output if it is one of the first
+// MaxFailedRuns with captured output
+func (s *CommandStats) addFailure(result *command.Result) {
+	c := result.Command
+	if c == nil || !c.CapturesOutput() || len(s.FailedRuns) >= MaxFailedRuns {
+		return
+	}
+	s.FailedRuns = append(s.FailedRuns, FailedRun{
+		Run:              s.TotalRuns,
+		ExitCode:         result.ExitCode,
+		Error:            result.Error.Error(),
+		ValidationFailed: result.ValidationFailed,
+		Stdout:           string(result.Stdout),
+		Stderr:           string(result.Stderr),
+	})
+}
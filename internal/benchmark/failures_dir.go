@@ -0,0 +1,146 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// DefaultFailuresLimit is how many failed runs of each command are saved to
+// Options.FailuresDir by default
+const DefaultFailuresLimit = 10
+
+// savedRun is how a failed run ended, saved as its run.json
+type savedRun struct {
+	Command   string        `json:"command"`
+	Run       int           `json:"run"`
+	StartTime time.Time     `json:"start_time"`
+	Duration  time.Duration `json:"duration_ns"`
+	Outcome   string        `json:"outcome"`
+	ExitCode  int           `json:"exit_code"`
+	Signal    string        `json:"signal,omitempty"`
+	Error     string        `json:"error,omitempty"`
+}
+
+// shouldSaveFailure returns whether result is a failed run to save to
+// Options.FailuresDir, counting it in stats if so. It is called with
+// statsMutex held.
+func (runner *Runner) shouldSaveFailure(stats *CommandStats, result *command.Result) bool {
+	if runner.Options.FailuresDir == "" || !result.Outcome().Failed() ||
+		stats.SavedFailures >= runner.Options.FailuresLimit {
+		return false
+	}
+	stats.SavedFailures++
+	return true
+}
+
+// saveFailure saves the run-th run of the command at index to
+// <FailuresDir>/<command>/<run>/: run.json with how it ended, env with the
+// environment it ran in, secrets redacted, and, if the command captures its
+// output, the beginning of it in stdout and stderr. Only the user can read
+// them, as the output may hold secrets too.
+func (runner *Runner) saveFailure(index, run int, result *command.Result) {
+	cmd := runner.Commands[index]
+	dir := filepath.Join(runner.Options.FailuresDir, commandDir(index, cmd.Raw), strconv.Itoa(run))
+	status := savedRun{
+		Command:   cmd.Raw,
+		Run:       run,
+		StartTime: result.StartTime,
+		Duration:  result.Duration,
+		Outcome:   result.Outcome().String(),
+		ExitCode:  result.ExitCode,
+		Signal:    result.Signal,
+	}
+	if result.Error != nil {
+		status.Error = result.Error.Error()
+	}
+
+	err := os.MkdirAll(dir, 0o700)
+	if err == nil {
+		err = writeJSON(filepath.Join(dir, "run.json"), status)
+	}
+	if err == nil {
+		env := strings.Join(redactEnv(os.Environ()), "\n") + "\n"
+		err = os.WriteFile(filepath.Join(dir, "env"), []byte(env), 0o600)
+	}
+	if err == nil && cmd.CapturesOutput() {
+		err = os.WriteFile(filepath.Join(dir, "stdout"), result.Stdout, 0o600)
+		if err == nil {
+			err = os.WriteFile(filepath.Join(dir, "stderr"), result.Stderr, 0o600)
+		}
+	}
+	if err != nil {
+		runner.statsMutex.Lock()
+		if runner.failuresErr == nil {
+			runner.failuresErr = err
+		}
+		runner.statsMutex.Unlock()
+	}
+}
+
+// FailuresErr returns the first error saving a failed run to
+// Options.FailuresDir. The benchmark goes on without the run.
+func (runner *Runner) FailuresErr() error {
+	runner.statsMutex.Lock()
+	defer runner.statsMutex.Unlock()
+	return runner.failuresErr
+}
+
+// commandDir names the directory of the command at index in
+// Options.FailuresDir: its number from 1, then its command line with
+// characters other than letters, digits, '.', '_' and '-' replaced, such as
+// "1-sleep-0.1"
+func commandDir(index int, raw string) string {
+	var name strings.Builder
+	dash := true
+	for _, r := range raw {
+		switch {
+		case name.Len() >= 60:
+		case r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("._-", r):
+			name.WriteRune(r)
+			dash = false
+		case !dash:
+			name.WriteByte('-')
+			dash = true
+		}
+	}
+	dir := strconv.Itoa(index + 1)
+	if slug := strings.Trim(name.String(), "-."); slug != "" {
+		dir += "-" + slug
+	}
+	return dir
+}
+
+// secretNames are the parts of the names of environment variables whose
+// values redactEnv leaves out, such as GITHUB_TOKEN or AWS_SECRET_ACCESS_KEY
+var secretNames = []string{"TOKEN", "SECRET", "KEY", "PASSWORD", "PASSWD", "CREDENTIAL"}
+
+// redactEnv returns env, a list of NAME=value, with the values of variables
+// that look like secrets replaced by <redacted>
+func redactEnv(env []string) []string {
+	redacted := make([]string, len(env))
+	for i, variable := range env {
+		redacted[i] = variable
+		name, _, _ := strings.Cut(variable, "=")
+		for _, secret := range secretNames {
+			if strings.Contains(strings.ToUpper(name), secret) {
+				redacted[i] = name + "=<redacted>"
+				break
+			}
+		}
+	}
+	return redacted
+}
+
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,99 @@
+package benchmark_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestFailuresDir(t *testing.T) {
+	t.Setenv("CMDPERF_TEST_TOKEN", "hunter2")
+	dir := t.TempDir()
+	flaky := command.NewShell(`echo out; echo err >&2; exit 3`, "/bin/sh", []string{"-c"})
+	flaky.CaptureOutput = true
+	ok := command.NewShell("true", "/bin/sh", []string{"-c"})
+	commands := []*command.Command{flaky, ok}
+	for _, cmd := range commands {
+		cmd.Parallelism = 1
+		cmd.Timeout = 5 * time.Second
+	}
+
+	runner, err := benchmark.NewRunner(commands, benchmark.Options{
+		Iterations:    5,
+		Parallelism:   1,
+		FailuresDir:   dir,
+		FailuresLimit: 2,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+	if err := runner.FailuresErr(); err != nil {
+		t.Fatalf("FailuresErr() = %v", err)
+	}
+
+	if saved := runner.Results[0].SavedFailures; saved != 2 {
+		t.Errorf("SavedFailures = %d, want the limit of 2", saved)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "1-echo-out-echo-err-2-exit-3" {
+		t.Fatalf("Directories %v, want only 1-echo-out-echo-err-2-exit-3", entries)
+	}
+	runs, err := os.ReadDir(filepath.Join(dir, entries[0].Name()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].Name() != "1" || runs[1].Name() != "2" {
+		t.Fatalf("Runs %v, want 1 and 2", runs)
+	}
+
+	run := filepath.Join(dir, entries[0].Name(), "1")
+	for file, want := range map[string]string{"stdout": "out\n", "stderr": "err\n"} {
+		if data, err := os.ReadFile(filepath.Join(run, file)); err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v, want %q", file, data, err, want)
+		}
+	}
+	env, err := os.ReadFile(filepath.Join(run, "env"))
+	if err != nil || !strings.Contains(string(env), "PATH=") {
+		t.Errorf("env = %q, %v, want the environment", env, err)
+	}
+	if !strings.Contains(string(env), "CMDPERF_TEST_TOKEN=<redacted>\n") || strings.Contains(string(env), "hunter2") {
+		t.Errorf("env = %q, want CMDPERF_TEST_TOKEN redacted", env)
+	}
+	for path, want := range map[string]os.FileMode{run: 0o700, filepath.Join(run, "env"): 0o600, filepath.Join(run, "stdout"): 0o600} {
+		if info, err := os.Stat(path); err != nil {
+			t.Error(err)
+		} else if info.Mode().Perm() != want {
+			t.Errorf("%s: mode %v, want %v", path, info.Mode().Perm(), want)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(run, "run.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var status struct {
+		Command  string `json:"command"`
+		Run      int    `json:"run"`
+		Duration int64  `json:"duration_ns"`
+		Outcome  string `json:"outcome"`
+		ExitCode int    `json:"exit_code"`
+	}
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatalf("Invalid run.json %s: %v", data, err)
+	}
+	if status.Command != flaky.Raw || status.Run != 1 || status.Duration <= 0 ||
+		status.Outcome != "unexpected_exit" || status.ExitCode != 3 {
+		t.Errorf("run.json = %s", data)
+	}
+}
@@ -0,0 +1,56 @@
+package benchmark_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestFailedRuns(t *testing.T) {
+	expect, err := command.ParseExpect("^ok$")
+	if err != nil {
+		t.Fatalf("ParseExpect failed: %v", err)
+	}
+	mismatch := command.NewShell("echo nope", "/bin/sh", []string{"-c"})
+	mismatch.ExpectStdout = expect
+	exit := command.NewShell("echo oops >&2; exit 2", "/bin/sh", []string{"-c"})
+	exit.CaptureOutput = true
+	uncaptured := command.NewShell("exit 2", "/bin/sh", []string{"-c"})
+	commands := []*command.Command{mismatch, exit, uncaptured}
+	for _, cmd := range commands {
+		cmd.Parallelism = 1
+		cmd.Timeout = 5 * time.Second
+	}
+
+	runner, err := benchmark.NewRunner(commands, benchmark.Options{Iterations: 5, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if stats.Outcomes.ValidationFailure != 5 || stats.ErrorCount != 5 {
+		t.Errorf("ValidationFailure = %d, ErrorCount = %d, want 5 each", stats.Outcomes.ValidationFailure, stats.ErrorCount)
+	}
+	if len(stats.FailedRuns) != benchmark.MaxFailedRuns {
+		t.Fatalf("Kept %d failed runs, want %d", len(stats.FailedRuns), benchmark.MaxFailedRuns)
+	}
+	if run := stats.FailedRuns[0]; run.Run != 1 || !run.ValidationFailed || run.Stdout != "nope\n" {
+		t.Errorf("FailedRuns[0] = %+v, want run 1 failing validation with its stdout", run)
+	}
+
+	stats = runner.Results[1]
+	if stats.Outcomes.ValidationFailure != 0 || stats.Outcomes.UnexpectedExit != 5 {
+		t.Errorf("Outcomes = %+v for exit 2, want 5 unexpected exits", stats.Outcomes)
+	}
+	if len(stats.FailedRuns) == 0 || stats.FailedRuns[0].ExitCode != 2 || stats.FailedRuns[0].Stderr != "oops\n" {
+		t.Errorf("FailedRuns = %+v, want exit status 2 with its stderr", stats.FailedRuns)
+	}
+
+	if failed := runner.Results[2].FailedRuns; failed != nil {
+		t.Errorf("FailedRuns = %+v without captured output", failed)
+	}
+}
@@ -0,0 +1,182 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// MinModeSamples is the fewest samples modes are looked for in
+	MinModeSamples = 20
+
+	// MinModeWeight is the smallest share of the samples a mode must hold;
+	// smaller bumps are left to outlier detection
+	MinModeWeight = 0.05
+
+	// densityPoints is the resolution the density is estimated at
+	densityPoints = 512
+
+	// A valley must dip below this share of the lower of the two peaks
+	// around it to separate them into modes
+	valleyDepth = 0.7
+)
+
+// Mode is a peak of a latency distribution
+type Mode struct {
+	// Value is the latency at the peak
+	Value time.Duration
+
+	// Weight is the share of the samples around this peak
+	Weight float64
+}
+
+// Modes are the peaks of a latency distribution, fastest first. More than one
+// means the command has distinct behaviors, such as cache hits and misses,
+// and that its mean describes none of them.
+type Modes []Mode
+
+// Multimodal reports whether there is more than one mode
+func (m Modes) Multimodal() bool {
+	return len(m) > 1
+}
+
+// String lists the modes, such as "1.02ms (70%), 10.4ms (30%)"
+func (m Modes) String() string {
+	parts := make([]string, len(m))
+	for i, mode := range m {
+		// Three significant digits
+		unit := time.Duration(math.Pow(10, math.Floor(math.Log10(float64(mode.Value)))-2))
+		parts[i] = fmt.Sprintf("%s (%.0f%%)", mode.Value.Round(max(unit, 1)), mode.Weight*100)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// findModes finds the modes of sorted samples with a kernel density
+// estimate, or returns nil with fewer than MinModeSamples samples. The
+// density is estimated over the logarithm of the durations, since latency
+// modes are typically apart by a factor rather than a fixed amount.
+func findModes(sorted []time.Duration) Modes {
+	n := len(sorted)
+	if n < MinModeSamples || sorted[0] <= 0 {
+		return nil
+	}
+
+	logs := make([]float64, n)
+	for i, sample := range sorted {
+		logs[i] = math.Log(float64(sample))
+	}
+	lo, hi := logs[0], logs[n-1]
+	if hi-lo < 1e-9 {
+		return Modes{{Value: sorted[n/2], Weight: 1}}
+	}
+
+	// Silverman's rule of thumb, using the interquartile range so the modes
+	// themselves don't widen it
+	sd := math.Sqrt(varianceFloat(logs))
+	iqr := quantileFloat(logs, 0.75) - quantileFloat(logs, 0.25)
+	spread := sd
+	if iqr > 0 && iqr/1.34 < spread {
+		spread = iqr / 1.34
+	}
+	bandwidth := 0.9 * spread * math.Pow(float64(n), -0.2)
+	if bandwidth <= 0 {
+		bandwidth = (hi - lo) / 100
+	}
+
+	// Estimate the density on a grid reaching a few bandwidths past the
+	// samples, only adding the samples within range of each point
+	from, to := lo-3*bandwidth, hi+3*bandwidth
+	step := (to - from) / (densityPoints - 1)
+	density := make([]float64, densityPoints)
+	first := 0
+	for i := range density {
+		x := from + float64(i)*step
+		for first < n && logs[first] < x-4*bandwidth {
+			first++
+		}
+		for j := first; j < n && logs[j] <= x+4*bandwidth; j++ {
+			u := (x - logs[j]) / bandwidth
+			density[i] += math.Exp(-u * u / 2)
+		}
+	}
+
+	// Peaks, merging those without a deep enough valley between them
+	var peaks, valleys []int
+	for i := 1; i < densityPoints-1; i++ {
+		if density[i] <= density[i-1] || density[i] < density[i+1] {
+			continue
+		}
+		if len(peaks) == 0 {
+			peaks = append(peaks, i)
+			continue
+		}
+		last := peaks[len(peaks)-1]
+		valley := last
+		for j := last; j <= i; j++ {
+			if density[j] < density[valley] {
+				valley = j
+			}
+		}
+		if density[valley] < valleyDepth*math.Min(density[last], density[i]) {
+			peaks = append(peaks, i)
+			valleys = append(valleys, valley)
+		} else if density[i] > density[last] {
+			peaks[len(peaks)-1] = i
+		}
+	}
+
+	// Weigh the modes by the samples between their valleys, dropping the
+	// ones too small to matter into their neighbours
+	modes := make(Modes, 0, len(peaks))
+	start := 0
+	for i, peak := range peaks {
+		end := n
+		if i < len(valleys) {
+			boundary := from + float64(valleys[i])*step
+			end = start
+			for end < n && logs[end] < boundary {
+				end++
+			}
+		}
+		weight := float64(end-start) / float64(n)
+		if weight >= MinModeWeight {
+			modes = append(modes, Mode{
+				Value:  time.Duration(math.Exp(from + float64(peak)*step)),
+				Weight: weight,
+			})
+		} else if len(modes) > 0 {
+			modes[len(modes)-1].Weight += weight
+		} else if i+1 < len(peaks) {
+			// Carried to the next mode
+			end = start
+		}
+		start = end
+	}
+	if len(modes) == 0 {
+		return Modes{{Value: sorted[n/2], Weight: 1}}
+	}
+	return modes
+}
+
+func varianceFloat(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	mean := meanFloat(xs)
+	var ss float64
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return ss / float64(len(xs)-1)
+}
+
+// quantileFloat interpolates the q-th quantile of sorted values
+func quantileFloat(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return sorted[lower]*(1-frac) + sorted[upper]*frac
+}
@@ -0,0 +1,68 @@
+package benchmark_test
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModesBimodal(t *testing.T) {
+	// 70% cache hits around 1ms, 30% misses around 10ms
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		base := time.Millisecond
+		if i%10 >= 7 {
+			base = 10 * time.Millisecond
+		}
+		durations[i] = base + base*time.Duration((i*7919)%11-5)/50
+	}
+
+	modes := runSequence(t, durations).Modes
+	if !modes.Multimodal() || len(modes) != 2 {
+		t.Fatalf("Modes = %v, want 2", modes)
+	}
+	if modes[0].Value < 900*time.Microsecond || modes[0].Value > 1100*time.Microsecond {
+		t.Errorf("First mode at %s, want about 1ms", modes[0].Value)
+	}
+	if modes[1].Value < 9*time.Millisecond || modes[1].Value > 11*time.Millisecond {
+		t.Errorf("Second mode at %s, want about 10ms", modes[1].Value)
+	}
+	if modes[0].Weight < 0.69 || modes[0].Weight > 0.71 || modes[1].Weight < 0.29 || modes[1].Weight > 0.31 {
+		t.Errorf("Weights %.2f and %.2f, want 0.7 and 0.3", modes[0].Weight, modes[1].Weight)
+	}
+}
+
+func TestModesUnimodal(t *testing.T) {
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		durations[i] = 10*time.Millisecond + jitter(i)
+	}
+
+	modes := runSequence(t, durations).Modes
+	if len(modes) != 1 || modes[0].Weight != 1 {
+		t.Errorf("Modes = %v, want one", modes)
+	}
+}
+
+func TestModesSmallBumpIgnored(t *testing.T) {
+	// 2 slow runs out of 100 are outliers, not a mode
+	durations := make([]time.Duration, 100)
+	for i := range durations {
+		durations[i] = 10*time.Millisecond + jitter(i)
+	}
+	durations[40], durations[80] = 50*time.Millisecond, 51*time.Millisecond
+
+	modes := runSequence(t, durations).Modes
+	if modes.Multimodal() {
+		t.Errorf("Modes = %v, want one", modes)
+	}
+}
+
+func TestModesTooFewSamples(t *testing.T) {
+	durations := make([]time.Duration, 10)
+	for i := range durations {
+		durations[i] = time.Duration(i+1) * time.Millisecond
+	}
+	if modes := runSequence(t, durations).Modes; modes != nil {
+		t.Errorf("Modes = %v, want nil", modes)
+	}
+}
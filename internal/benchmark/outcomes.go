@@ -0,0 +1,68 @@
+package benchmark
+
+import (
+	"maps"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// Outcomes counts a command's runs by how they ended, see command.Outcome
+type Outcomes struct {
+	Success           int `json:"success"`
+	UnexpectedExit    int `json:"unexpected_exit"`
+	Signaled          int `json:"signal"`
+	Timeout           int `json:"timeout"`
+	SpawnFailure      int `json:"spawn_failure"`
+	ValidationFailure int `json:"validation_failure"`
+	Cancelled         int `json:"cancelled"`
+
+	// ExitCodes counts the unexpected exits by exit status, and Signals the
+	// signaled runs by signal name, such as SIGSEGV
+	ExitCodes map[int]int    `json:"unexpected_exit_codes,omitempty"`
+	Signals   map[string]int `json:"signals,omitempty"`
+}
+
+// add counts a run
+func (o *Outcomes) add(result *command.Result) {
+	switch result.Outcome() {
+	case command.OutcomeSuccess:
+		o.Success++
+	case command.OutcomeUnexpectedExit:
+		o.UnexpectedExit++
+		if o.ExitCodes == nil {
+			o.ExitCodes = make(map[int]int)
+		}
+		o.ExitCodes[result.ExitCode]++
+	case command.OutcomeSignaled:
+		o.Signaled++
+		if o.Signals == nil {
+			o.Signals = make(map[string]int)
+		}
+		o.Signals[result.Signal]++
+	case command.OutcomeTimeout:
+		o.Timeout++
+	case command.OutcomeSpawnFailure:
+		o.SpawnFailure++
+	case command.OutcomeValidationFailure:
+		o.ValidationFailure++
+	case command.OutcomeCancelled:
+		o.Cancelled++
+	}
+}
+
+// Failed returns the number of runs that failed, leaving out the ones
+// cancelled by the end of the benchmark
+func (o *Outcomes) Failed() int {
+	return o.UnexpectedExit + o.Signaled + o.Timeout + o.SpawnFailure + o.ValidationFailure
+}
+
+// clone copies the outcomes, so that the copy shares no maps with them
+func (o Outcomes) clone() Outcomes {
+	if o.ExitCodes != nil {
+		o.ExitCodes = maps.Clone(o.ExitCodes)
+	}
+	if o.Signals != nil {
+		o.Signals = maps.Clone(o.Signals)
+	}
+	return o
+}
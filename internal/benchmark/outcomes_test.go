@@ -0,0 +1,59 @@
+package benchmark_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestOutcomes(t *testing.T) {
+	exit := command.NewShell("exit 2", "/bin/sh", []string{"-c"})
+	killed := command.NewShell(`kill -KILL $$`, "/bin/sh", []string{"-c"})
+	expected := command.NewShell("exit 2", "/bin/sh", []string{"-c"})
+	expected.ExpectExit = []int{2}
+	commands := []*command.Command{exit, killed, expected}
+	for _, cmd := range commands {
+		cmd.Parallelism = 1
+		cmd.Timeout = 5 * time.Second
+	}
+
+	runner, err := benchmark.NewRunner(commands, benchmark.Options{Iterations: 3, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	var mu sync.Mutex
+	var failed []*benchmark.RunResult
+	runner.SetEventHandler(func(event benchmark.Event) {
+		if progress, ok := event.(*benchmark.CommandProgress); ok && progress.Result != nil {
+			mu.Lock()
+			failed = append(failed, progress.Result)
+			mu.Unlock()
+		}
+	})
+	runner.Run(context.Background())
+
+	if o := runner.Results[0].Outcomes; o.UnexpectedExit != 3 || o.ExitCodes[2] != 3 {
+		t.Errorf("exit 2: Outcomes = %+v, want 3 unexpected exits with status 2", o)
+	}
+	if o := runner.Results[1].Outcomes; o.Signaled != 3 || o.Signals["SIGKILL"] != 3 {
+		t.Errorf("kill: Outcomes = %+v, want 3 killed by SIGKILL", o)
+	}
+	if o := runner.Results[2].Outcomes; o.Success != 3 || runner.Results[2].ErrorCount != 0 {
+		t.Errorf("expected exit 2: Outcomes = %+v, ErrorCount = %d, want 3 successes", o, runner.Results[2].ErrorCount)
+	}
+
+	outcomes := map[string]bool{}
+	for _, run := range failed {
+		outcomes[run.Outcome] = true
+		if run.Outcome == "signal" && run.Signal != "SIGKILL" {
+			t.Errorf("Signaled run with Signal %q, want SIGKILL", run.Signal)
+		}
+	}
+	if !outcomes["unexpected_exit"] || !outcomes["signal"] {
+		t.Errorf("Failed run events with outcomes %v, want unexpected_exit and signal", outcomes)
+	}
+}
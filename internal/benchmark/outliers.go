@@ -0,0 +1,177 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// How outliers are treated, see Options.Outliers
+const (
+	// OutliersKeep leaves outliers in the statistics without looking for them
+	OutliersKeep = "keep"
+	// OutliersReport counts outliers but leaves them in the statistics
+	OutliersReport = "report"
+	// OutliersDrop counts outliers and leaves them out of the statistics
+	// taken from the samples, see dropOutliers
+	OutliersDrop = "drop"
+)
+
+// Tukey's fences: samples more than 1.5 interquartile ranges outside the
+// quartiles are mild outliers, more than 3 are severe
+const (
+	mildFence   = 1.5
+	severeFence = 3.0
+)
+
+// Outliers classifies a command's samples with Tukey's fences. Low outliers
+// are unusually fast runs, high ones unusually slow, such as runs that hit
+// interference from other processes.
+type Outliers struct {
+	LowSevere, LowMild, HighMild, HighSevere int
+
+	// Samples is the number of samples that were classified, which is the
+	// representative sample of the runs kept for percentiles
+	Samples int
+
+	// VarianceShare is the part of the variance of all samples that is due
+	// to the outliers: near 1 when a few spikes cause it, near 0 when the
+	// spread is genuine
+	VarianceShare float64
+
+	// Dropped is set when the outliers were left out of the statistics
+	Dropped bool
+}
+
+// Total returns the number of outliers
+func (o *Outliers) Total() int {
+	return o.LowSevere + o.LowMild + o.HighMild + o.HighSevere
+}
+
+// Severe returns the number of severe outliers
+func (o *Outliers) Severe() int {
+	return o.LowSevere + o.HighSevere
+}
+
+// String summarizes the outliers, such as "3 of 100 samples (2 high
+// severe, 1 high mild) causing 85% of the variance"
+func (o *Outliers) String() string {
+	var parts []string
+	for _, class := range []struct {
+		name  string
+		count int
+	}{
+		{"low severe", o.LowSevere},
+		{"low mild", o.LowMild},
+		{"high mild", o.HighMild},
+		{"high severe", o.HighSevere},
+	} {
+		if class.count > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", class.count, class.name))
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Sprintf("none of %d samples", o.Samples)
+	}
+
+	s := fmt.Sprintf("%d of %d samples (%s) causing %.0f%% of the variance",
+		o.Total(), o.Samples, strings.Join(parts, ", "), o.VarianceShare*100)
+	if o.Dropped {
+		s += ", dropped"
+	}
+	return s
+}
+
+// classifyOutliers classifies sorted samples, and returns the samples that
+// are not outliers
+func classifyOutliers(sorted []time.Duration) (*Outliers, []time.Duration) {
+	outliers := &Outliers{Samples: len(sorted)}
+	if len(sorted) < 4 {
+		// Too few samples for quartiles to mean anything
+		return outliers, sorted
+	}
+
+	q1 := quantile(sorted, 0.25)
+	q3 := quantile(sorted, 0.75)
+	iqr := q3 - q1
+
+	inliers := make([]time.Duration, 0, len(sorted))
+	for _, sample := range sorted {
+		x := float64(sample)
+		switch {
+		case x < q1-severeFence*iqr:
+			outliers.LowSevere++
+		case x < q1-mildFence*iqr:
+			outliers.LowMild++
+		case x > q3+severeFence*iqr:
+			outliers.HighSevere++
+		case x > q3+mildFence*iqr:
+			outliers.HighMild++
+		default:
+			inliers = append(inliers, sample)
+		}
+	}
+
+	if total := variance(sorted); total > 0 && len(inliers) < len(sorted) {
+		// Measured around the mean of all samples, so the shares of the
+		// outliers and the inliers add up
+		mean := meanOf(sorted)
+		var inlierSS float64
+		for _, sample := range inliers {
+			d := float64(sample) - mean
+			inlierSS += d * d
+		}
+		outliers.VarianceShare = math.Max(0, 1-inlierSS/(total*float64(len(sorted)-1)))
+	}
+	return outliers, inliers
+}
+
+// dropOutliers recalculates the statistics taken from the samples from the
+// ones that are not outliers: the range, mean, standard deviation and, later,
+// the median and percentiles. With more runs than samples kept, they are all
+// those of the representative sample rather than of every run. Counts of
+// runs, throughput, precision and drift still cover every run, since the runs
+// did happen.
+func dropOutliers(stats *CommandStats, inliers []time.Duration) {
+	if len(inliers) == 0 {
+		return
+	}
+	stats.Outliers.Dropped = true
+	stats.MedianSamples = inliers
+	stats.Min = inliers[0]
+	stats.Max = inliers[len(inliers)-1]
+	stats.Mean = time.Duration(meanOf(inliers))
+	stats.StdDev = time.Duration(math.Sqrt(variance(inliers)))
+}
+
+// quantile interpolates the q-th quantile of sorted samples
+func quantile(sorted []time.Duration, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	frac := pos - float64(lower)
+	return float64(sorted[lower])*(1-frac) + float64(sorted[upper])*frac
+}
+
+func meanOf(samples []time.Duration) float64 {
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample)
+	}
+	return sum / float64(len(samples))
+}
+
+// variance returns the sample variance
+func variance(samples []time.Duration) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+	mean := meanOf(samples)
+	var ss float64
+	for _, sample := range samples {
+		d := float64(sample) - mean
+		ss += d * d
+	}
+	return ss / float64(len(samples)-1)
+}
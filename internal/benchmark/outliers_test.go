@@ -0,0 +1,128 @@
+package benchmark_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// sequenceExecutor reports the given durations in turn, without running
+// anything
+type sequenceExecutor struct {
+	cmd       *command.Command
+	durations []time.Duration
+	runs      atomic.Int64
+}
+
+func (e *sequenceExecutor) Execute(ctx context.Context) *command.Result {
+	i := e.runs.Add(1) - 1
+	return &command.Result{
+		Command:   e.cmd,
+		StartTime: time.Now(),
+		Duration:  e.durations[int(i)%len(e.durations)],
+	}
+}
+
+// spikyDurations are 20 runs between 10ms and 11.9ms, one of them a mild
+// high outlier at 14ms and two severe ones around 50ms
+func spikyDurations() []time.Duration {
+	durations := make([]time.Duration, 0, 20)
+	for i := 0; i < 17; i++ {
+		durations = append(durations, 10*time.Millisecond+time.Duration(i)*100*time.Microsecond)
+	}
+	return append(durations, 14*time.Millisecond, 50*time.Millisecond, 52*time.Millisecond)
+}
+
+func runOutliers(t *testing.T, mode string) *benchmark.CommandStats {
+	t.Helper()
+
+	durations := spikyDurations()
+	cmd := &command.Command{Raw: "spiky", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: durations}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{
+		Iterations:  len(durations),
+		Parallelism: 1,
+		Outliers:    mode,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+	return runner.Results[0]
+}
+
+func TestOutliersReport(t *testing.T) {
+	stats := runOutliers(t, "")
+
+	outliers := stats.Outliers
+	if outliers == nil {
+		t.Fatal("Outliers = nil, want them reported by default")
+	}
+	if outliers.HighSevere != 2 || outliers.HighMild != 1 || outliers.LowMild+outliers.LowSevere != 0 {
+		t.Errorf("Outliers = %+v, want 2 high severe and 1 high mild", *outliers)
+	}
+	if outliers.Samples != 20 {
+		t.Errorf("Samples = %d, want 20", outliers.Samples)
+	}
+	if outliers.VarianceShare < 0.8 {
+		t.Errorf("VarianceShare = %.2f, want the spikes to cause most of the variance", outliers.VarianceShare)
+	}
+	if outliers.Dropped {
+		t.Error("Dropped = true, want false")
+	}
+	if stats.Max != 52*time.Millisecond {
+		t.Errorf("Max = %s, want the outliers kept in the statistics", stats.Max)
+	}
+}
+
+func TestOutliersDrop(t *testing.T) {
+	stats := runOutliers(t, benchmark.OutliersDrop)
+
+	if stats.Outliers == nil || !stats.Outliers.Dropped || stats.Outliers.Total() != 3 {
+		t.Fatalf("Outliers = %+v, want 3 dropped", stats.Outliers)
+	}
+	if stats.Max >= 12*time.Millisecond {
+		t.Errorf("Max = %s, want the outliers left out", stats.Max)
+	}
+	if stats.Mean < 10*time.Millisecond || stats.Mean > 11*time.Millisecond {
+		t.Errorf("Mean = %s, want about 10.8ms without the outliers", stats.Mean)
+	}
+	if stats.HighVariance {
+		t.Error("HighVariance = true, want false without the outliers")
+	}
+	if stats.TotalRuns != 20 {
+		t.Errorf("TotalRuns = %d, want all 20 runs counted", stats.TotalRuns)
+	}
+}
+
+func TestOutliersKeep(t *testing.T) {
+	stats := runOutliers(t, benchmark.OutliersKeep)
+	if stats.Outliers != nil {
+		t.Errorf("Outliers = %+v, want nil", *stats.Outliers)
+	}
+}
+
+func TestOutliersGenuineSpread(t *testing.T) {
+	// Evenly spread samples have no outliers, however wide the spread
+	durations := make([]time.Duration, 20)
+	for i := range durations {
+		durations[i] = time.Duration(i+1) * time.Millisecond
+	}
+	cmd := &command.Command{Raw: "spread", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: durations}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{Iterations: 20, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	if outliers := runner.Results[0].Outliers; outliers.Total() != 0 || outliers.VarianceShare != 0 {
+		t.Errorf("Outliers = %+v, want none", *outliers)
+	}
+}
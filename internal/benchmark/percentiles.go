@@ -0,0 +1,97 @@
+package benchmark
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// MaxSamples is the most samples kept per command, enough for p99.999
+	MaxSamples = 1000000
+
+	// tailSamples is how many samples are kept above the highest requested
+	// percentile, so it isn't simply the slowest sample seen
+	tailSamples = 10
+)
+
+// DefaultPercentiles are the percentiles reported when Options.Percentiles
+// is empty
+var DefaultPercentiles = []float64{50, 95, 99}
+
+// Percentile is a latency percentile of a command
+type Percentile struct {
+	// P is the percentile, such as 99.9
+	P float64
+
+	// Value is the latency P percent of the runs took at most
+	Value time.Duration
+}
+
+// FormatPercentile formats a percentile without trailing zeros, such as
+// "99.9" for 99.9 and "50" for 50
+func FormatPercentile(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
+
+// normalizePercentiles checks and sorts percentiles, dropping duplicates
+func normalizePercentiles(percentiles []float64) ([]float64, error) {
+	if len(percentiles) == 0 {
+		return DefaultPercentiles, nil
+	}
+	sorted := append([]float64(nil), percentiles...)
+	sort.Float64s(sorted)
+	normalized := sorted[:0]
+	for _, p := range sorted {
+		if math.IsNaN(p) || p <= 0 || p > 100 {
+			return nil, fmt.Errorf("benchmark: percentile %s must be above 0 and at most 100", FormatPercentile(p))
+		}
+		if len(normalized) == 0 || normalized[len(normalized)-1] != p {
+			normalized = append(normalized, p)
+		}
+	}
+	return normalized, nil
+}
+
+// sampleLimit returns how many samples to keep for percentiles, so that
+// there are at least tailSamples samples above the highest of them. It is
+// MaxMedianSamples for p99 and below, and grows tenfold for every further 9.
+func sampleLimit(percentiles []float64) int {
+	highest := percentiles[len(percentiles)-1]
+	if highest >= 100 {
+		return MaxSamples
+	}
+	limit := int(math.Ceil(tailSamples * 100 / (100 - highest)))
+	return min(max(limit, MaxMedianSamples), MaxSamples)
+}
+
+// percentileOf returns the p-th percentile of sorted samples, for p from 0
+// to 100
+func percentileOf(sorted []time.Duration, p float64) time.Duration {
+	n := len(sorted)
+	if n == 0 {
+		return 0
+	}
+	idx := int(p / 100 * float64(n))
+	if idx >= n {
+		idx = n - 1
+	}
+	return sorted[idx]
+}
+
+// CDF returns the cumulative distribution of the samples at a fixed
+// resolution, as the percentiles 0, 100/points, 2·100/points, ... 100, or
+// nil without samples. It is only complete after the benchmark.
+func (s *CommandStats) CDF(points int) []Percentile {
+	if len(s.MedianSamples) == 0 || points <= 0 {
+		return nil
+	}
+	cdf := make([]Percentile, points+1)
+	for i := range cdf {
+		p := 100 * float64(i) / float64(points)
+		cdf[i] = Percentile{P: p, Value: percentileOf(s.MedianSamples, p)}
+	}
+	return cdf
+}
@@ -0,0 +1,102 @@
+package benchmark_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func runPercentiles(t *testing.T, runs int, percentiles []float64) *benchmark.CommandStats {
+	t.Helper()
+
+	// Runs taking 1µs, 2µs, ... in a scrambled order
+	durations := make([]time.Duration, runs)
+	for i := range durations {
+		durations[i] = time.Duration((i*7919)%runs+1) * time.Microsecond
+	}
+	cmd := &command.Command{Raw: "sequence", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &sequenceExecutor{cmd: cmd, durations: durations}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{
+		Iterations:  runs,
+		Parallelism: 1,
+		Outliers:    benchmark.OutliersKeep,
+		Percentiles: percentiles,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+	return runner.Results[0]
+}
+
+func TestPercentilesDefault(t *testing.T) {
+	stats := runPercentiles(t, 200, nil)
+
+	want := []benchmark.Percentile{{P: 50, Value: stats.P50}, {P: 95, Value: stats.P95}, {P: 99, Value: stats.P99}}
+	if len(stats.Percentiles) != len(want) {
+		t.Fatalf("Percentiles = %v, want %v", stats.Percentiles, want)
+	}
+	for i := range want {
+		if stats.Percentiles[i] != want[i] {
+			t.Errorf("Percentiles[%d] = %v, want %v", i, stats.Percentiles[i], want[i])
+		}
+	}
+	if stats.P99 != 199*time.Microsecond {
+		t.Errorf("P99 = %s, want 199µs", stats.P99)
+	}
+}
+
+func TestPercentilesHighKeepsMoreSamples(t *testing.T) {
+	// 1000 samples would leave p99.9 at the slowest sample of a random
+	// thousand; p99.9 keeps them all
+	stats := runPercentiles(t, 5000, []float64{99.9, 50, 99.9})
+
+	if len(stats.MedianSamples) != 5000 {
+		t.Errorf("Kept %d samples, want 5000", len(stats.MedianSamples))
+	}
+	if len(stats.Percentiles) != 2 || stats.Percentiles[0].P != 50 || stats.Percentiles[1].P != 99.9 {
+		t.Fatalf("Percentiles = %v, want 50 and 99.9 in order", stats.Percentiles)
+	}
+	if got := stats.Percentiles[1].Value; got != 4996*time.Microsecond {
+		t.Errorf("P99.9 = %s, want 4.996ms", got)
+	}
+}
+
+func TestPercentilesInvalid(t *testing.T) {
+	cmd := &command.Command{Raw: "true", Parallelism: 1}
+	for _, p := range []float64{0, -1, 100.5} {
+		_, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{
+			Iterations:  1,
+			Parallelism: 1,
+			Percentiles: []float64{50, p},
+		})
+		if err == nil {
+			t.Errorf("NewRunner accepted percentile %v", p)
+		}
+	}
+}
+
+func TestCDF(t *testing.T) {
+	stats := runPercentiles(t, 100, nil)
+
+	cdf := stats.CDF(4)
+	want := []benchmark.Percentile{
+		{P: 0, Value: time.Microsecond},
+		{P: 25, Value: 26 * time.Microsecond},
+		{P: 50, Value: 51 * time.Microsecond},
+		{P: 75, Value: 76 * time.Microsecond},
+		{P: 100, Value: 100 * time.Microsecond},
+	}
+	if len(cdf) != len(want) {
+		t.Fatalf("CDF = %v, want %v", cdf, want)
+	}
+	for i := range want {
+		if cdf[i] != want[i] {
+			t.Errorf("CDF[%d] = %v, want %v", i, cdf[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package benchmark
+
+import (
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// Names of the phases of HTTP requests, in order, see command.Phases
+const (
+	PhaseDNS     = "dns"
+	PhaseConnect = "connect"
+	PhaseTLS     = "tls"
+	PhaseTTFB    = "ttfb"
+	PhaseTotal   = "total"
+)
+
+var phaseNames = [...]string{PhaseDNS, PhaseConnect, PhaseTLS, PhaseTTFB, PhaseTotal}
+
+// Phase is the mean duration of a phase of a command's HTTP requests, over
+// the Runs that went through it. Requests on a kept-alive connection skip
+// the dns, connect and tls phases.
+type Phase struct {
+	Name string
+	Mean time.Duration
+	Runs int
+}
+
+// phaseSums adds up the phases of a command's requests
+type phaseSums struct {
+	sum  [len(phaseNames)]time.Duration
+	runs [len(phaseNames)]int
+}
+
+// add adds the phases of a request that got a response, and its total
+// duration
+func (p *phaseSums) add(phases command.Phases, total time.Duration) {
+	for i, d := range [...]time.Duration{phases.DNS, phases.Connect, phases.TLS, phases.TTFB, total} {
+		if d > 0 {
+			p.sum[i] += d
+			p.runs[i]++
+		}
+	}
+}
+
+// phases returns the mean of every phase some request went through, or nil
+// without requests
+func (p *phaseSums) phases() []Phase {
+	if p.runs[len(phaseNames)-1] == 0 {
+		return nil
+	}
+	var phases []Phase
+	for i, name := range phaseNames {
+		if p.runs[i] > 0 {
+			phases = append(phases, Phase{Name: name, Mean: p.sum[i] / time.Duration(p.runs[i]), Runs: p.runs[i]})
+		}
+	}
+	return phases
+}
@@ -0,0 +1,64 @@
+package benchmark_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// keepAliveExecutor reports the phases of requests on one kept-alive
+// connection: the first connects, the rest reuse it
+type keepAliveExecutor struct {
+	cmd  *command.Command
+	runs atomic.Int32
+}
+
+func (e *keepAliveExecutor) Execute(ctx context.Context) *command.Result {
+	result := &command.Result{Command: e.cmd, StartTime: time.Now(), Duration: 3 * time.Millisecond}
+	result.Phases.TTFB = 2 * time.Millisecond
+	if e.runs.Add(1) == 1 {
+		result.Phases.Connect = time.Millisecond
+		result.Phases.TTFB += time.Millisecond
+		result.Duration += time.Millisecond
+	}
+	return result
+}
+
+func TestPhases(t *testing.T) {
+	cmd := &command.Command{Raw: "http://localhost", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &keepAliveExecutor{cmd: cmd}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{Iterations: 10, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	want := []benchmark.Phase{
+		{Name: benchmark.PhaseConnect, Mean: time.Millisecond, Runs: 1},
+		{Name: benchmark.PhaseTTFB, Mean: 2100 * time.Microsecond, Runs: 10},
+		{Name: benchmark.PhaseTotal, Mean: 3100 * time.Microsecond, Runs: 10},
+	}
+	got := runner.Results[0].Phases
+	if len(got) != len(want) {
+		t.Fatalf("Phases = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Phase %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestPhasesWithoutHTTP(t *testing.T) {
+	runner := newSleepRunner(t, benchmark.Options{Iterations: 2, Parallelism: 1}, "true")
+	runner.Run(context.Background())
+
+	if phases := runner.Results[0].Phases; phases != nil {
+		t.Errorf("Phases = %+v, want none for a process", phases)
+	}
+}
@@ -0,0 +1,187 @@
+package benchmark_test
+
+import (
+	"context"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// alternatingExecutor reports durations alternating between fast and slow,
+// without running anything
+type alternatingExecutor struct {
+	cmd        *command.Command
+	fast, slow time.Duration
+	runs       atomic.Int64
+}
+
+func (e *alternatingExecutor) Execute(ctx context.Context) *command.Result {
+	duration := e.fast
+	if e.runs.Add(1)%2 == 0 {
+		duration = e.slow
+	}
+	time.Sleep(100 * time.Microsecond)
+	return &command.Result{Command: e.cmd, StartTime: time.Now(), Duration: duration}
+}
+
+func newPrecisionRunner(t *testing.T, options benchmark.Options, fast, slow time.Duration) *benchmark.Runner {
+	t.Helper()
+
+	cmd := &command.Command{Raw: "alternating", Parallelism: 1, Timeout: time.Second}
+	cmd.Executor = &alternatingExecutor{cmd: cmd, fast: fast, slow: slow}
+
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, options)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	if runner.Mode != benchmark.ModePrecision {
+		t.Fatalf("Mode = %v, want ModePrecision", runner.Mode)
+	}
+	return runner
+}
+
+func TestRunnerTargetPrecisionConverges(t *testing.T) {
+	// Durations of 9ms and 11ms have a 10% coefficient of variation, so ±1%
+	// takes about (1.97 * 10 / 1)² ≈ 388 runs
+	runner := newPrecisionRunner(t, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.01,
+		MaxTime:         time.Minute,
+	}, 9*time.Millisecond, 11*time.Millisecond)
+
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if !stats.Converged {
+		t.Fatalf("Converged = false after %d runs, precision %.4f", stats.TotalRuns, stats.Precision)
+	}
+	if stats.Precision > 0.01 {
+		t.Errorf("Precision = %.4f, want at most 0.01", stats.Precision)
+	}
+	if stats.TotalRuns < 350 || stats.TotalRuns > 450 {
+		t.Errorf("TotalRuns = %d, want about 388", stats.TotalRuns)
+	}
+	if stats.TargetRuns != stats.TotalRuns {
+		t.Errorf("TargetRuns = %d, want TotalRuns %d once converged", stats.TargetRuns, stats.TotalRuns)
+	}
+}
+
+func TestRunnerTargetPrecisionMinRuns(t *testing.T) {
+	// Identical durations converge immediately, but not before MinRuns
+	runner := newPrecisionRunner(t, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.01,
+		MinRuns:         25,
+	}, 10*time.Millisecond, 10*time.Millisecond)
+
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if !stats.Converged {
+		t.Fatalf("Converged = false after %d runs", stats.TotalRuns)
+	}
+	if stats.TotalRuns < 25 || stats.TotalRuns > 25+benchmark.DefaultBatchSize {
+		t.Errorf("TotalRuns = %d, want 25 plus at most a batch", stats.TotalRuns)
+	}
+}
+
+func TestRunnerTargetPrecisionMaxRuns(t *testing.T) {
+	runner := newPrecisionRunner(t, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.001,
+		MaxRuns:         40,
+	}, 5*time.Millisecond, 15*time.Millisecond)
+
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if stats.Converged {
+		t.Errorf("Converged = true, want false at ±%.2f%%", stats.Precision*100)
+	}
+	if stats.TotalRuns != 40 {
+		t.Errorf("TotalRuns = %d, want MaxRuns 40", stats.TotalRuns)
+	}
+	if stats.TargetRuns != 40 {
+		t.Errorf("TargetRuns = %d, want MaxRuns 40", stats.TargetRuns)
+	}
+}
+
+func TestRunnerTargetPrecisionStudentT(t *testing.T) {
+	// 9ms and 11ms alternating have a standard error of 1/3 of a millisecond
+	// over 10 runs, which Student's t with 9 degrees of freedom widens to
+	// ±2.2622/30 of the mean, where the normal distribution's 1.96 gives
+	// ±6.5%
+	runner := newPrecisionRunner(t, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.001,
+		MinRuns:         10,
+		MaxRuns:         10,
+	}, 9*time.Millisecond, 11*time.Millisecond)
+
+	runner.Run(context.Background())
+
+	if precision := runner.Results[0].Precision; math.Abs(precision-2.2622/30) > 1e-4 {
+		t.Errorf("Precision = %.4f, want %.4f", precision, 2.2622/30)
+	}
+}
+
+func TestRunnerTargetPrecisionAllFailing(t *testing.T) {
+	// A missing shell fails every run without a sample
+	cmd := command.NewShell("true", "/nonexistent/shell", []string{"-c"})
+	cmd.Parallelism = 1
+	cmd.Timeout = time.Second
+	runner, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.01,
+		MinRuns:         5,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+
+	runner.Run(context.Background())
+
+	stats := runner.Results[0]
+	if stats.Converged {
+		t.Error("Converged = true, want false without a successful run")
+	}
+	if stats.TotalRuns < 5 || stats.TotalRuns > 5+benchmark.DefaultBatchSize {
+		t.Errorf("TotalRuns = %d, want 5 plus at most a batch", stats.TotalRuns)
+	}
+}
+
+func TestRunnerTargetPrecisionMaxTime(t *testing.T) {
+	runner := newPrecisionRunner(t, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.0001,
+		MaxTime:         300 * time.Millisecond,
+	}, 5*time.Millisecond, 15*time.Millisecond)
+
+	start := time.Now()
+	runner.Run(context.Background())
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Run took %s, want it stopped after MaxTime", elapsed)
+	}
+	if runner.Results[0].Converged {
+		t.Error("Converged = true, want false")
+	}
+}
+
+func TestNewRunnerTargetPrecisionValidation(t *testing.T) {
+	cmd := &command.Command{Raw: "true", Shell: "/bin/sh", ShellOptions: []string{"-c"}}
+	for name, options := range map[string]benchmark.Options{
+		"with duration":    {TargetPrecision: 0.01, Duration: time.Second},
+		"max below min":    {TargetPrecision: 0.01, MinRuns: 20, MaxRuns: 10},
+		"100% or more":     {TargetPrecision: 1},
+		"nothing to reach": {},
+	} {
+		if _, err := benchmark.NewRunner([]*command.Command{cmd}, options); err == nil {
+			t.Errorf("%s: NewRunner succeeded, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package benchmark
+
+import "sync"
+
+// workQueue hands out iteration numbers to a command's workers. Unlike a
+// pre-filled channel, its limit can be raised while the command is running.
+// The queue closes once every worker is waiting on an exhausted limit, so an
+// extension that arrives while runs are still in flight is never lost.
+type workQueue struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	issued  int
+	limit   int // negative means unlimited
+	workers int
+	waiting int
+	closed  bool
+}
+
+func newWorkQueue(limit, workers int) *workQueue {
+	q := &workQueue{limit: limit, workers: workers}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// next blocks until an iteration is available, returning false once the
+// queue is closed
+func (q *workQueue) next() (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.closed {
+			return 0, false
+		}
+		if q.limit < 0 || q.issued < q.limit {
+			i := q.issued
+			q.issued++
+			return i, true
+		}
+
+		q.waiting++
+		if q.waiting == q.workers {
+			// Nobody is left running who could observe an extension
+			q.closed = true
+			q.cond.Broadcast()
+			return 0, false
+		}
+		q.cond.Wait()
+		q.waiting--
+	}
+}
+
+// extend raises the limit by n, returning the new limit and false if the
+// queue has already closed
+func (q *workQueue) extend(n int) (int, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed || q.limit < 0 {
+		return q.limit, false
+	}
+	q.limit += n
+	q.cond.Broadcast()
+	return q.limit, true
+}
+
+// currentLimit returns the number of iterations the queue will hand out
+func (q *workQueue) currentLimit() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.limit
+}
+
+// isClosed reports whether the queue has stopped handing out iterations
+func (q *workQueue) isClosed() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.closed
+}
+
+// close wakes all waiting workers and stops handing out iterations
+func (q *workQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.closed = true
+	q.cond.Broadcast()
+}
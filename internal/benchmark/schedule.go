@@ -0,0 +1,169 @@
+package benchmark
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// How the runs of different commands are ordered, see Options.Schedule
+const (
+	// ScheduleParallel runs all commands at the same time, each with its own
+	// workers
+	ScheduleParallel = "parallel"
+
+	// ScheduleSequential runs the commands one after another
+	ScheduleSequential = "sequential"
+
+	// ScheduleInterleaved takes turns between the commands, A, B, C, A, B,
+	// C..., so that background noise affects them alike
+	ScheduleInterleaved = "interleaved"
+
+	// ScheduleRandom takes turns like ScheduleInterleaved, in a new random
+	// order every round, so that periodic noise can't line up with one command
+	ScheduleRandom = "random"
+)
+
+// turns lets one command run at a time, for a turn of block runs. The
+// command's workers take a turn's runs as they come, so with Parallelism
+// workers a turn is Parallelism runs at the same time.
+type turns struct {
+	mu      sync.Mutex
+	changed chan struct{} // closed and replaced when the turn passes
+
+	block    int
+	order    []int
+	pos      int
+	current  int // command whose turn it is, -1 once all are done
+	started  int // runs started this turn
+	inFlight int
+	done     []bool
+	rng      *rand.Rand // shuffles every round; nil keeps the order
+}
+
+func newTurns(commands, block int, rng *rand.Rand) *turns {
+	t := &turns{
+		changed: make(chan struct{}),
+		block:   block,
+		order:   make([]int, commands),
+		done:    make([]bool, commands),
+		rng:     rng,
+	}
+	for i := range t.order {
+		t.order[i] = i
+	}
+	t.shuffle()
+	t.current = t.order[0]
+	return t
+}
+
+// acquire blocks until it is the command's turn and the turn has runs left,
+// returning false if ctx is done first. Every successful acquire must be
+// followed by a release.
+func (t *turns) acquire(ctx context.Context, command int) bool {
+	for {
+		t.mu.Lock()
+		if t.current == command && t.started < t.block {
+			t.started++
+			t.inFlight++
+			t.mu.Unlock()
+			return true
+		}
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release ends a run, passing the turn on after the last run of a turn
+func (t *turns) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight--
+	if t.started >= t.block && t.inFlight == 0 {
+		t.advance()
+	}
+}
+
+// finish takes a command that has no more runs out of the rotation
+func (t *turns) finish(command int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done[command] {
+		return
+	}
+	t.done[command] = true
+	if t.current == command {
+		t.advance()
+	}
+}
+
+// advance passes the turn to the next command that isn't done; must be
+// called with mu held
+func (t *turns) advance() {
+	t.started = 0
+	defer func() {
+		close(t.changed)
+		t.changed = make(chan struct{})
+	}()
+
+	left := false
+	for _, done := range t.done {
+		left = left || !done
+	}
+	if !left {
+		t.current = -1
+		return
+	}
+	for {
+		t.pos++
+		if t.pos == len(t.order) {
+			t.pos = 0
+			t.shuffle()
+		}
+		if next := t.order[t.pos]; !t.done[next] {
+			t.current = next
+			return
+		}
+	}
+}
+
+func (t *turns) shuffle() {
+	if t.rng != nil {
+		t.rng.Shuffle(len(t.order), func(i, j int) {
+			t.order[i], t.order[j] = t.order[j], t.order[i]
+		})
+	}
+}
+
+// runSequentially runs the commands one after another. In duration mode
+// each command runs for Options.Duration, which Extend and Pause move for the
+// command running.
+func (runner *Runner) runSequentially(ctx context.Context) {
+	for i, cmd := range runner.Commands {
+		cmdCtx, cancel := context.WithCancel(ctx)
+		if runner.Mode == ModeDuration {
+			runner.controlMu.Lock()
+			start := time.Now()
+			if runner.resumeCh != nil {
+				// Resume adds the time since the pause started
+				start = runner.pausedAt
+			}
+			runner.deadline = start.Add(runner.Options.Duration)
+			runner.controlMu.Unlock()
+			go runner.enforceDeadline(cmdCtx, cancel)
+		}
+
+		runner.wg.Add(1)
+		runner.runCommand(cmdCtx, i, cmd)
+		cancel()
+	}
+}
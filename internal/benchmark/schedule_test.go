@@ -0,0 +1,187 @@
+package benchmark_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// orderLog records the order runs of several commands started in, and
+// whether runs of different commands overlapped
+type orderLog struct {
+	mu       sync.Mutex
+	order    strings.Builder
+	running  map[string]int
+	overlaps int
+}
+
+// loggingExecutor takes a millisecond per run, logging its name to log
+type loggingExecutor struct {
+	cmd  *command.Command
+	name string
+	log  *orderLog
+}
+
+func (e *loggingExecutor) Execute(ctx context.Context) *command.Result {
+	e.log.mu.Lock()
+	e.log.order.WriteString(e.name)
+	e.log.running[e.name]++
+	if len(e.log.running) > 1 {
+		e.log.overlaps++
+	}
+	e.log.mu.Unlock()
+
+	start := time.Now()
+	time.Sleep(time.Millisecond)
+
+	e.log.mu.Lock()
+	if e.log.running[e.name]--; e.log.running[e.name] == 0 {
+		delete(e.log.running, e.name)
+	}
+	e.log.mu.Unlock()
+	return &command.Result{Command: e.cmd, StartTime: start, Duration: time.Since(start)}
+}
+
+func runSchedule(t *testing.T, options benchmark.Options, names ...string) (*benchmark.Runner, *orderLog) {
+	t.Helper()
+
+	log := &orderLog{running: make(map[string]int)}
+	commands := make([]*command.Command, len(names))
+	for i, name := range names {
+		commands[i] = &command.Command{Raw: name, Parallelism: options.Parallelism, Timeout: time.Second}
+		commands[i].Executor = &loggingExecutor{cmd: commands[i], name: name, log: log}
+	}
+
+	runner, err := benchmark.NewRunner(commands, options)
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+	return runner, log
+}
+
+func TestScheduleInterleaved(t *testing.T) {
+	_, log := runSchedule(t, benchmark.Options{Iterations: 3, Parallelism: 1, Schedule: benchmark.ScheduleInterleaved}, "A", "B", "C")
+
+	if got := log.order.String(); got != "ABCABCABC" {
+		t.Errorf("Order = %s, want ABCABCABC", got)
+	}
+}
+
+func TestScheduleInterleavedBlocks(t *testing.T) {
+	// With two workers, a turn is two runs at once
+	_, log := runSchedule(t, benchmark.Options{Iterations: 6, Parallelism: 2, Schedule: benchmark.ScheduleInterleaved}, "A", "B")
+
+	if got := log.order.String(); got != "AABBAABBAABB" {
+		t.Errorf("Order = %s, want AABBAABBAABB", got)
+	}
+	if log.overlaps != 0 {
+		t.Errorf("Runs of different commands overlapped %d times", log.overlaps)
+	}
+}
+
+func TestScheduleInterleavedUneven(t *testing.T) {
+	// Commands that finish leave the rotation
+	_, log := runSchedule(t, benchmark.Options{Iterations: 3, Parallelism: 2, Schedule: benchmark.ScheduleInterleaved}, "A", "B")
+
+	if got := log.order.String(); got != "AABBAB" {
+		t.Errorf("Order = %s, want AABBAB", got)
+	}
+}
+
+func TestScheduleSequential(t *testing.T) {
+	_, log := runSchedule(t, benchmark.Options{Iterations: 3, Parallelism: 2, Schedule: benchmark.ScheduleSequential}, "A", "B", "C")
+
+	if got := log.order.String(); got != "AAABBBCCC" {
+		t.Errorf("Order = %s, want AAABBBCCC", got)
+	}
+	if log.overlaps != 0 {
+		t.Errorf("Runs of different commands overlapped %d times", log.overlaps)
+	}
+}
+
+func TestScheduleSequentialDuration(t *testing.T) {
+	// Every command runs for the whole duration
+	start := time.Now()
+	runner, log := runSchedule(t, benchmark.Options{Duration: 100 * time.Millisecond, Parallelism: 1, Schedule: benchmark.ScheduleSequential}, "A", "B")
+
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Took %s, want 100ms per command", elapsed)
+	}
+	for _, stats := range runner.Results {
+		if stats.SuccessfulRuns < 20 {
+			t.Errorf("%s ran %d times, want it to run for 100ms", stats.Command.Raw, stats.SuccessfulRuns)
+		}
+	}
+	if got := log.order.String(); strings.Contains(strings.TrimLeft(got, "A"), "A") {
+		t.Errorf("Order = %s, want all A before B", got)
+	}
+}
+
+func TestScheduleRandom(t *testing.T) {
+	options := benchmark.Options{Iterations: 20, Parallelism: 1, Schedule: benchmark.ScheduleRandom}
+	runner, log := runSchedule(t, options, "A", "B", "C")
+
+	seed := runner.Options.Seed
+	if seed == 0 {
+		t.Fatal("Seed = 0, want one picked and recorded")
+	}
+	order := log.order.String()
+	for round := 0; round < 20; round++ {
+		block := order[3*round : 3*round+3]
+		if !strings.Contains(block, "A") || !strings.Contains(block, "B") || !strings.Contains(block, "C") {
+			t.Fatalf("Round %d is %s, want every command once: %s", round, block, order)
+		}
+	}
+	if order == strings.Repeat("ABC", 20) {
+		t.Errorf("Order = %s, want it shuffled", order)
+	}
+
+	// The recorded seed repeats the order
+	options.Seed = seed
+	if _, again := runSchedule(t, options, "A", "B", "C"); again.order.String() != order {
+		t.Errorf("Seed %d gave %s, then %s", seed, order, again.order.String())
+	}
+}
+
+func TestScheduleInterleavedPrecision(t *testing.T) {
+	// A converges at the minimum runs and leaves B to run alone
+	log := &orderLog{running: make(map[string]int)}
+	a := &command.Command{Raw: "A", Parallelism: 1, Timeout: time.Second}
+	a.Executor = &sequenceExecutor{cmd: a, durations: []time.Duration{time.Millisecond}}
+	b := &command.Command{Raw: "B", Parallelism: 1, Timeout: time.Second}
+	b.Executor = &loggingExecutor{cmd: b, name: "B", log: log}
+
+	runner, err := benchmark.NewRunner([]*command.Command{a, b}, benchmark.Options{
+		Parallelism:     1,
+		TargetPrecision: 0.0001,
+		MinRuns:         5,
+		MaxRuns:         30,
+		Schedule:        benchmark.ScheduleInterleaved,
+	})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+	runner.Run(context.Background())
+
+	if runs := runner.Results[0].TotalRuns; runs != 5 {
+		t.Errorf("A ran %d times, want 5", runs)
+	}
+	if runs := runner.Results[1].TotalRuns; runs != 30 {
+		t.Errorf("B ran %d times, want 30", runs)
+	}
+}
+
+func TestScheduleInvalid(t *testing.T) {
+	cmd := &command.Command{Raw: "true", Parallelism: 1}
+	_, err := benchmark.NewRunner([]*command.Command{cmd}, benchmark.Options{Iterations: 1, Parallelism: 1, Schedule: "round-robin"})
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("%q", "round-robin")) {
+		t.Errorf("err = %v, want an invalid schedule", err)
+	}
+}
@@ -0,0 +1,39 @@
+package benchmark
+
+import (
+	"fmt"
+	"time"
+)
+
+// Thresholds are upper bounds a command's statistics must stay within.
+// A zero value disables the corresponding check.
+type Thresholds struct {
+	MaxMean time.Duration
+	MaxP95  time.Duration
+}
+
+// Enabled reports whether any threshold is set
+func (t Thresholds) Enabled() bool {
+	return t.MaxMean > 0 || t.MaxP95 > 0
+}
+
+// Check returns a description of every threshold the stats violate. A
+// command without a successful run violates every threshold that is set,
+// since it has nothing to meet them with.
+func (t Thresholds) Check(stats *CommandStats) []string {
+	if stats == nil || !t.Enabled() {
+		return nil
+	}
+	if stats.SuccessfulRuns == 0 {
+		return []string{"no successful runs to check the thresholds against"}
+	}
+
+	var violations []string
+	if t.MaxMean > 0 && stats.Mean > t.MaxMean {
+		violations = append(violations, fmt.Sprintf("mean %s exceeds %s", stats.Mean, t.MaxMean))
+	}
+	if t.MaxP95 > 0 && stats.P95 > t.MaxP95 {
+		violations = append(violations, fmt.Sprintf("p95 %s exceeds %s", stats.P95, t.MaxP95))
+	}
+	return violations
+}
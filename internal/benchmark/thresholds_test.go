@@ -0,0 +1,35 @@
+package benchmark_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+func TestThresholdsCheck(t *testing.T) {
+	stats := &benchmark.CommandStats{SuccessfulRuns: 10, Mean: 10 * time.Millisecond, P95: 20 * time.Millisecond}
+	tests := []struct {
+		name       string
+		thresholds benchmark.Thresholds
+		stats      *benchmark.CommandStats
+		want       []string
+	}{
+		{"disabled", benchmark.Thresholds{}, stats, nil},
+		{"within", benchmark.Thresholds{MaxMean: 10 * time.Millisecond, MaxP95: 30 * time.Millisecond}, stats, nil},
+		{"mean", benchmark.Thresholds{MaxMean: 5 * time.Millisecond}, stats, []string{"mean 10ms exceeds 5ms"}},
+		{"p95", benchmark.Thresholds{MaxMean: time.Second, MaxP95: 15 * time.Millisecond}, stats, []string{"p95 20ms exceeds 15ms"}},
+		{"both", benchmark.Thresholds{MaxMean: time.Millisecond, MaxP95: time.Millisecond}, stats,
+			[]string{"mean 10ms exceeds 1ms", "p95 20ms exceeds 1ms"}},
+		{"no successful runs", benchmark.Thresholds{MaxMean: time.Second}, &benchmark.CommandStats{TotalRuns: 10, ErrorCount: 10},
+			[]string{"no successful runs to check the thresholds against"}},
+		{"no successful runs, disabled", benchmark.Thresholds{}, &benchmark.CommandStats{TotalRuns: 10, ErrorCount: 10}, nil},
+		{"no stats", benchmark.Thresholds{MaxMean: time.Second}, nil, nil},
+	}
+	for _, test := range tests {
+		if got := test.thresholds.Check(test.stats); !slices.Equal(got, test.want) {
+			t.Errorf("%s: Check() = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
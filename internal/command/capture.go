@@ -0,0 +1,111 @@
+package command
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// CaptureLimit is how much of each of a run's stdout and stderr its Result
+// keeps; the rest is read and dropped
+const CaptureLimit = 64 << 10
+
+// Expect is what the stdout of every run of a command must be: a match of
+// Regexp within its first CaptureLimit bytes, or the whole of it hashing to
+// SHA256
+type Expect struct {
+	// Spec is the expectation as given to ParseExpect
+	Spec string
+
+	Regexp *regexp.Regexp
+	SHA256 []byte
+}
+
+// ParseExpect parses an expectation of stdout: "sha256:<hex>" of the whole
+// output, "file:<path>" for the exact contents of a file, or a regular
+// expression, optionally prefixed "regex:", to find in it. ^ and $ match at
+// the start and end of lines, as with grep.
+func ParseExpect(spec string) (*Expect, error) {
+	e := &Expect{Spec: spec}
+	kind, value, ok := strings.Cut(spec, ":")
+	switch {
+	case ok && kind == "sha256":
+		sum, err := hex.DecodeString(value)
+		if err != nil || len(sum) != sha256.Size {
+			return nil, fmt.Errorf("invalid sha256 %q: use 64 hex digits", value)
+		}
+		e.SHA256 = sum
+	case ok && kind == "file":
+		data, err := os.ReadFile(value)
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		e.SHA256 = sum[:]
+	default:
+		if ok && kind == "regex" {
+			spec = value
+		}
+		re, err := regexp.Compile("(?m)" + spec)
+		if err != nil {
+			return nil, err
+		}
+		e.Regexp = re
+	}
+	return e, nil
+}
+
+// check returns whether a run's output matches, from its capture
+func (e *Expect) check(out *capture) bool {
+	if e.Regexp != nil {
+		return e.Regexp.Match(out.buf.Bytes())
+	}
+	return bytes.Equal(out.hash.Sum(nil), e.SHA256)
+}
+
+// capture keeps the first CaptureLimit bytes written to it, and hashes all of
+// them with hash set
+type capture struct {
+	buf  bytes.Buffer
+	hash hash.Hash
+}
+
+func (c *capture) Write(p []byte) (int, error) {
+	if room := CaptureLimit - c.buf.Len(); room > 0 {
+		c.buf.Write(p[:min(len(p), room)])
+	}
+	if c.hash != nil {
+		c.hash.Write(p)
+	}
+	return len(p), nil
+}
+
+// CapturesOutput returns whether the Results of c keep their output
+func (c *Command) CapturesOutput() bool {
+	return c.CaptureOutput || c.ExpectStdout != nil
+}
+
+// SetCaptureOutput sets CaptureOutput when capture is set, or when
+// saveFailures is set so the output of failed runs can be saved with them.
+// Commands run by an Executor have no output to save.
+func (c *Command) SetCaptureOutput(capture, saveFailures bool) {
+	c.CaptureOutput = capture || (saveFailures && c.Executor == nil)
+}
+
+// captureOutput connects the stdout and stderr of a run of c to captures, or
+// returns nils if c doesn't capture its output
+func (c *Command) captureOutput() (stdout, stderr *capture) {
+	if !c.CapturesOutput() {
+		return nil, nil
+	}
+	stdout, stderr = &capture{}, &capture{}
+	if c.ExpectStdout != nil && c.ExpectStdout.SHA256 != nil {
+		stdout.hash = sha256.New()
+	}
+	return stdout, stderr
+}
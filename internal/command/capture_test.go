@@ -0,0 +1,92 @@
+//go:build !windows
+
+package command_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestParseExpect(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "expected")
+	if err := os.WriteFile(path, []byte("hello\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write expected output: %v", err)
+	}
+	sum := sha256.Sum256([]byte("hello\n"))
+
+	for _, spec := range []string{"^hello$", "regex:^hel+o", "file:" + path, "sha256:" + hex.EncodeToString(sum[:])} {
+		expect, err := command.ParseExpect(spec)
+		if err != nil {
+			t.Errorf("ParseExpect(%q) failed: %v", spec, err)
+			continue
+		}
+		if expect.Spec != spec {
+			t.Errorf("Spec = %q, want %q", expect.Spec, spec)
+		}
+	}
+
+	for _, spec := range []string{"a(", "sha256:abc", "file:" + filepath.Join(t.TempDir(), "missing")} {
+		if _, err := command.ParseExpect(spec); err == nil {
+			t.Errorf("ParseExpect(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestExpectStdout(t *testing.T) {
+	// 100 KB of output, beyond what is captured, followed by "done"
+	raw := "head -c 100000 /dev/zero | tr '\\0' x; echo; echo done"
+	var all strings.Builder
+	all.WriteString(strings.Repeat("x", 100000) + "\ndone\n")
+	sum := sha256.Sum256([]byte(all.String()))
+
+	tests := []struct {
+		raw, expect string
+		ok          bool
+	}{
+		{"echo hello", "^hello$", true},
+		{"echo hello world", "^hello$", false},
+		{raw, "sha256:" + hex.EncodeToString(sum[:]), true},
+		{raw, "sha256:" + strings.Repeat("00", sha256.Size), false},
+		{"false", "^$", true}, // failed with exit status 1, not validation
+	}
+	for _, test := range tests {
+		expect, err := command.ParseExpect(test.expect)
+		if err != nil {
+			t.Fatalf("ParseExpect(%q) failed: %v", test.expect, err)
+		}
+		cmd := command.NewShell(test.raw, "/bin/sh", []string{"-c"})
+		cmd.Timeout = 5 * time.Second
+		cmd.ExpectStdout = expect
+
+		result := cmd.Execute(context.Background())
+		if result.ValidationFailed == test.ok {
+			t.Errorf("%s with %s: ValidationFailed = %t (%v)", test.raw, test.expect, result.ValidationFailed, result.Error)
+		}
+		if len(result.Stdout) > command.CaptureLimit {
+			t.Errorf("%s: captured %d bytes, over the limit", test.raw, len(result.Stdout))
+		}
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	cmd := command.NewShell("echo out; echo err >&2", "/bin/sh", []string{"-c"})
+	cmd.Timeout = 5 * time.Second
+
+	if result := cmd.Execute(context.Background()); result.Stdout != nil || result.Stderr != nil {
+		t.Errorf("Captured %q and %q without CaptureOutput", result.Stdout, result.Stderr)
+	}
+
+	cmd.CaptureOutput = true
+	result := cmd.Execute(context.Background())
+	if string(result.Stdout) != "out\n" || string(result.Stderr) != "err\n" {
+		t.Errorf("Stdout = %q, Stderr = %q, want out and err", result.Stdout, result.Stderr)
+	}
+}
@@ -2,8 +2,11 @@ package command
 
 import (
 	"context"
-	"os/exec"
+	"errors"
+	"slices"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 )
 
@@ -15,14 +18,99 @@ type Command struct {
 	Timeout      time.Duration
 	Parallelism  int
 
-	// Cached shell options to avoid repeated allocations
-	cachedShellOptions []string
+	// KillSignal, unless zero, is sent to the process group of a run that
+	// timed out or was cancelled, and SIGKILL only if it still runs
+	// KillGrace later, so it can clean up. Result.Signal records which one
+	// ended it.
+	KillSignal syscall.Signal
+	KillGrace  time.Duration
+
+	// shellArgs are ShellOptions followed by Raw, built once by NewShell so
+	// runs don't allocate them
+	shellArgs []string
 
 	// DirectExec indicates whether to execute the command directly without a shell
 	DirectExec bool
 
+	// InShell is set for commands run in a persistent shell, whose runs don't
+	// include starting a process, see NewPersistent
+	InShell bool
+
+	// Stdin, when set, is fed to every run of a shell or direct command
+	Stdin *Stdin
+
+	// CaptureOutput keeps the beginning of the stdout and stderr of every run
+	// of a shell or direct command in its Result. ExpectStdout, which
+	// implies it, fails runs that exit 0 with an unexpected stdout.
+	CaptureOutput bool
+	ExpectStdout  *Expect
+
+	// ExpectExit lists the exit statuses of successful runs of a process,
+	// 0 when empty
+	ExpectExit []int
+
 	Command string
 	Args    []string
+
+	// Executor runs the command instead of a process when set; Raw then only
+	// names it
+	Executor Executor
+}
+
+// NewShell creates a command that runs raw through shell with shellOptions
+func NewShell(raw, shell string, shellOptions []string) *Command {
+	return &Command{
+		Raw:          raw,
+		Shell:        shell,
+		ShellOptions: shellOptions,
+		shellArgs:    append(slices.Clip(shellOptions), raw),
+	}
+}
+
+// NewDirect creates a command that executes raw without a shell. raw is split
+// on spaces, except inside single or double quotes.
+func NewDirect(raw string) (*Command, error) {
+	parts := splitArgs(raw)
+	if len(parts) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return &Command{
+		Raw:        raw,
+		DirectExec: true,
+		Command:    parts[0],
+		Args:       parts[1:],
+	}, nil
+}
+
+func splitArgs(cmd string) []string {
+	var parts []string
+	var current strings.Builder
+	inQuotes := false
+	quoteChar := rune(0)
+
+	for _, r := range cmd {
+		switch {
+		case (r == '"' || r == '\'') && !inQuotes:
+			inQuotes = true
+			quoteChar = r
+		case r == quoteChar && inQuotes:
+			inQuotes = false
+			quoteChar = rune(0)
+		case r == ' ' && !inQuotes:
+			if current.Len() > 0 {
+				parts = append(parts, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+
+	return parts
 }
 
 // Result represents the result of a single command execution
@@ -35,6 +123,29 @@ type Result struct {
 	TimedOut         bool
 	ContextCancelled bool // New field to track context cancellation
 	SpawnFailed      bool // Command never started; Duration is not a valid timing sample
+
+	// Signal is the name of the signal that killed the process, such as
+	// SIGSEGV, or "" if it exited
+	Signal string
+
+	// Phases of an HTTP request, zero for other commands
+	Phases Phases
+
+	// Stdout and Stderr are the first CaptureLimit bytes of the run's output
+	// with Command.CaptureOutput or ExpectStdout. ValidationFailed is set
+	// when the command exited 0 but its stdout didn't match ExpectStdout.
+	Stdout, Stderr   []byte
+	ValidationFailed bool
+}
+
+// Phases are the durations of the phases of an HTTP request. DNS, Connect
+// and TLS are zero for requests on a kept-alive connection; TTFB, the time
+// to the first byte of the response, counts from the start of the request.
+type Phases struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration
 }
 
 // Object pool for Result objects to reduce allocations
@@ -46,98 +157,20 @@ var resultPool = sync.Pool{
 
 // Execute runs the command once and returns the result
 func (c *Command) Execute(ctx context.Context) *Result {
-	// Get a result from the pool instead of allocating a new one
-	result := resultPool.Get().(*Result)
-
-	// Reset the result fields
-	result.Command = c
-	result.StartTime = time.Now()
-	result.Duration = 0
-	result.ExitCode = 0
-	result.Error = nil
-	result.TimedOut = false
-	result.ContextCancelled = false // Reset the new field
-	result.SpawnFailed = false
-
-	// Check if context is already cancelled
-	if ctx.Err() != nil {
-		result.Error = ctx.Err()
-		result.ContextCancelled = true
-		result.Duration = time.Since(result.StartTime)
-		return result
-	}
-
-	// Create a context with timeout if timeout is set
-	var execCtx context.Context
-	var cancel context.CancelFunc
-	if c.Timeout > 0 {
-		execCtx, cancel = context.WithTimeout(ctx, c.Timeout)
-		defer cancel()
-	} else {
-		execCtx = ctx
-		cancel = func() {} // No-op cancel function
-	}
-
-	// Create a command based on execution mode
-	var cmd *exec.Cmd
-	if c.DirectExec {
-		// Direct execution mode
-		cmd = exec.CommandContext(execCtx, c.Command, c.Args...)
-		setSysProcAttr(cmd)
-	} else {
-		// Shell execution mode - use cached options if available
-		if c.cachedShellOptions == nil {
-			c.cachedShellOptions = make([]string, len(c.ShellOptions)+1)
-			copy(c.cachedShellOptions, c.ShellOptions)
-			c.cachedShellOptions[len(c.ShellOptions)] = c.Raw
-		}
-		cmd = exec.CommandContext(execCtx, c.Shell, c.cachedShellOptions...)
-		setSysProcAttr(cmd)
-	}
-
-	// Execute and capture timing
-	startTime := time.Now()
-	doneCh := make(chan struct{})
-	if err := cmd.Start(); err != nil {
-		result.Error = err
-		result.ExitCode = -1
-		result.SpawnFailed = true
-		result.Duration = time.Since(startTime)
-		return result
-	}
-	go func() {
-		select {
-		case <-execCtx.Done():
-			if cmd.Process != nil {
-				killProcessGroup(cmd.Process.Pid)
-			}
-		case <-doneCh:
-		}
-	}()
-	err := cmd.Wait()
-	close(doneCh)
-	endTime := time.Now()
-	result.Duration = endTime.Sub(startTime)
-
-	// Handle execution results
-	if err != nil {
-		result.Error = err
-		if exitErr, ok := err.(*exec.ExitError); ok {
-			result.ExitCode = exitErr.ExitCode()
-		} else {
-			result.ExitCode = -1
-		}
-
-		if execCtx.Err() == context.DeadlineExceeded {
-			result.TimedOut = true
-		}
-
-		// Check if parent context was cancelled (duration elapsed)
-		if ctx.Err() != nil {
-			result.ContextCancelled = true
-		}
+	switch {
+	case c.Executor != nil:
+		return c.Executor.Execute(ctx)
+	case c.DirectExec:
+		return DirectExecutor{c}.Execute(ctx)
+	default:
+		return ShellExecutor{c}.Execute(ctx)
 	}
+}
 
+// newResult takes a Result for a run of c from the pool
+func newResult(c *Command) *Result {
+	result := resultPool.Get().(*Result)
+	*result = Result{Command: c, StartTime: time.Now()}
 	return result
 }
 
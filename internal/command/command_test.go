@@ -65,3 +65,26 @@ func TestCommandTimeout(t *testing.T) {
 		t.Error("Expected an error due to timeout, got nil")
 	}
 }
+
+func TestNewDirect(t *testing.T) {
+	cmd, err := command.NewDirect(`grep -e "a b" 'file name'`)
+	if err != nil {
+		t.Fatalf("NewDirect failed: %v", err)
+	}
+	if !cmd.DirectExec || cmd.Command != "grep" {
+		t.Errorf("Unexpected command: %+v", cmd)
+	}
+	want := []string{"-e", "a b", "file name"}
+	if len(cmd.Args) != len(want) {
+		t.Fatalf("Args = %q, want %q", cmd.Args, want)
+	}
+	for i := range want {
+		if cmd.Args[i] != want[i] {
+			t.Errorf("Args = %q, want %q", cmd.Args, want)
+		}
+	}
+
+	if _, err := command.NewDirect("   "); err == nil {
+		t.Error("NewDirect with an empty command expected error, got nil")
+	}
+}
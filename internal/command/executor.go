@@ -0,0 +1,158 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"slices"
+	"time"
+)
+
+// Executor runs one iteration of a command's workload, such as a process, a
+// request to a local service or a Go function. It fills in a Result like
+// Command.Execute does, honoring the command's timeout and ctx's
+// cancellation. Executors that hold resources between runs, such as
+// connections, implement io.Closer to release them once the command is done.
+type Executor interface {
+	Execute(ctx context.Context) *Result
+}
+
+// ShellExecutor runs a command through its shell, the default for commands
+// without an Executor
+type ShellExecutor struct {
+	Command *Command
+}
+
+func (e ShellExecutor) Execute(ctx context.Context) *Result {
+	c := e.Command
+	return runProcess(ctx, c, func(execCtx context.Context) *exec.Cmd {
+		args := c.shellArgs
+		if args == nil {
+			// A Command made without NewShell
+			args = append(slices.Clip(c.ShellOptions), c.Raw)
+		}
+		return exec.CommandContext(execCtx, c.Shell, args...)
+	})
+}
+
+// DirectExecutor executes a command's Command and Args without a shell, see
+// NewDirect
+type DirectExecutor struct {
+	Command *Command
+}
+
+func (e DirectExecutor) Execute(ctx context.Context) *Result {
+	c := e.Command
+	return runProcess(ctx, c, func(execCtx context.Context) *exec.Cmd {
+		return exec.CommandContext(execCtx, c.Command, c.Args...)
+	})
+}
+
+// runProcess runs the process newCmd creates once, terminating its process
+// group at the timeout
+func runProcess(ctx context.Context, c *Command, newCmd func(execCtx context.Context) *exec.Cmd) *Result {
+	result := newResult(c)
+
+	// Check if context is already cancelled
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		result.ContextCancelled = true
+		result.Duration = time.Since(result.StartTime)
+		return result
+	}
+
+	execCtx, cancel := withTimeout(ctx, c.Timeout)
+	defer cancel()
+
+	cmd := newCmd(execCtx)
+	setSysProcAttr(cmd)
+	if c.KillSignal != 0 {
+		// The process group gets KillSignal below, instead of exec killing
+		// the process
+		cmd.Cancel = func() error { return nil }
+	}
+	if c.Stdin != nil {
+		stdin, closeStdin, err := c.Stdin.open()
+		if err != nil {
+			result.Error = err
+			result.ExitCode = -1
+			result.SpawnFailed = true
+			return result
+		}
+		defer closeStdin()
+		cmd.Stdin = stdin
+	}
+	stdout, stderr := c.captureOutput()
+	if stdout != nil {
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+	}
+
+	// Execute and capture timing
+	startTime := time.Now()
+	doneCh := make(chan struct{})
+	if err := cmd.Start(); err != nil {
+		result.Error = err
+		result.ExitCode = -1
+		result.SpawnFailed = true
+		result.Duration = time.Since(startTime)
+		return result
+	}
+	go func() {
+		select {
+		case <-execCtx.Done():
+			if cmd.Process != nil {
+				terminateProcessGroup(cmd.Process.Pid, c.KillSignal, c.KillGrace, doneCh)
+			}
+		case <-doneCh:
+		}
+	}()
+	err := cmd.Wait()
+	close(doneCh)
+	endTime := time.Now()
+	result.Duration = endTime.Sub(startTime)
+
+	if stdout != nil {
+		result.Stdout, result.Stderr = stdout.buf.Bytes(), stderr.buf.Bytes()
+	}
+
+	// Handle execution results
+	if err != nil {
+		result.fail(ctx, execCtx, err)
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			result.ExitCode = exitErr.ExitCode()
+		}
+	}
+	if cmd.ProcessState != nil {
+		result.Signal = signalName(cmd.ProcessState)
+	}
+	c.checkExit(result)
+
+	if result.Error == nil && c.ExpectStdout != nil && !c.ExpectStdout.check(stdout) {
+		result.Error = fmt.Errorf("stdout doesn't match %s", c.ExpectStdout.Spec)
+		result.ValidationFailed = true
+	}
+
+	return result
+}
+
+// withTimeout derives the context of a run from ctx, with timeout unless it
+// is zero
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func() {}
+}
+
+// fail records err as the run's failure, telling a timeout of the run's
+// execCtx from the cancellation of the benchmark's ctx (duration elapsed)
+func (r *Result) fail(ctx, execCtx context.Context, err error) {
+	r.Error = err
+	r.ExitCode = -1
+	if execCtx.Err() == context.DeadlineExceeded {
+		r.TimedOut = true
+	}
+	if ctx.Err() != nil {
+		r.ContextCancelled = true
+	}
+}
@@ -0,0 +1,189 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+)
+
+// maxIdleConns is how many keep-alive connections an HTTPExecutor keeps per
+// host, one per worker
+const maxIdleConns = 1024
+
+// HTTPOptions configure the request of an HTTP command
+type HTTPOptions struct {
+	Header http.Header
+	Body   []byte
+
+	// NoKeepAlive opens a new connection for every run, so that every run
+	// pays for DNS, connecting and TLS
+	NoKeepAlive bool
+
+	// ExpectStatus lists the statuses of successful runs; without, statuses
+	// below 400 are
+	ExpectStatus []int
+}
+
+// HTTPExecutor sends an HTTP request for every run, reusing connections
+// between runs like a client of the service would unless NoKeepAlive. A run
+// takes until the whole response body is read, and fails with the status
+// code as its exit code for unexpected statuses. Its Result has the Phases
+// of the request.
+type HTTPExecutor struct {
+	cmd     *Command
+	Method  string
+	URL     string
+	Options HTTPOptions
+	client  *http.Client
+}
+
+// NewHTTP creates a command that sends a GET request to rawURL, an http or
+// https URL
+func NewHTTP(rawURL string) (*Command, error) {
+	return NewHTTPRequest(http.MethodGet, rawURL, HTTPOptions{})
+}
+
+// NewHTTPRequest creates a command that sends a method request to rawURL, an
+// http or https URL. It is named after the URL, preceded by the method
+// unless GET.
+func NewHTTPRequest(method, rawURL string, options HTTPOptions) (*Command, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an http or https URL", rawURL)
+	}
+	// Validates the method and headers
+	if _, err := http.NewRequest(method, rawURL, nil); err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 0
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	transport.DisableKeepAlives = options.NoKeepAlive
+
+	c := &Command{Raw: rawURL}
+	if method != http.MethodGet {
+		c.Raw = method + " " + rawURL
+	}
+	c.Executor = &HTTPExecutor{
+		cmd:     c,
+		Method:  method,
+		URL:     rawURL,
+		Options: options,
+		client:  &http.Client{Transport: transport},
+	}
+	return c, nil
+}
+
+func (e *HTTPExecutor) Execute(ctx context.Context) *Result {
+	result := newResult(e.cmd)
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		result.ContextCancelled = true
+		return result
+	}
+
+	execCtx, cancel := withTimeout(ctx, e.cmd.Timeout)
+	defer cancel()
+
+	var body io.Reader
+	if e.Options.Body != nil {
+		body = bytes.NewReader(e.Options.Body)
+	}
+	req, err := http.NewRequest(e.Method, e.URL, body)
+	if err != nil {
+		result.Error = err
+		result.ExitCode = -1
+		result.SpawnFailed = true
+		return result
+	}
+	for name, values := range e.Options.Header {
+		req.Header[name] = values
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	startTime := time.Now()
+	tracer := &phaseTracer{start: startTime}
+	req = req.WithContext(httptrace.WithClientTrace(execCtx, tracer.trace()))
+	resp, err := e.client.Do(req)
+	if err == nil {
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	result.Duration = time.Since(startTime)
+	result.Phases = tracer.phases()
+
+	switch {
+	case err != nil:
+		result.fail(ctx, execCtx, err)
+	case !e.expected(resp.StatusCode):
+		result.Error = fmt.Errorf("HTTP %s", resp.Status)
+		result.ExitCode = resp.StatusCode
+	}
+	return result
+}
+
+func (e *HTTPExecutor) expected(status int) bool {
+	if len(e.Options.ExpectStatus) == 0 {
+		return status < 400
+	}
+	return slices.Contains(e.Options.ExpectStatus, status)
+}
+
+// Close closes the idle connections
+func (e *HTTPExecutor) Close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// phaseTracer times the phases of a request. Dialing several addresses at
+// once calls it concurrently.
+type phaseTracer struct {
+	mu                            sync.Mutex
+	start                         time.Time
+	dnsStart, connStart, tlsStart time.Time
+	p                             Phases
+}
+
+func (t *phaseTracer) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { t.mark(&t.dnsStart) },
+		DNSDone:              func(httptrace.DNSDoneInfo) { t.since(&t.p.DNS, &t.dnsStart) },
+		ConnectStart:         func(_, _ string) { t.mark(&t.connStart) },
+		ConnectDone:          func(_, _ string, _ error) { t.since(&t.p.Connect, &t.connStart) },
+		TLSHandshakeStart:    func() { t.mark(&t.tlsStart) },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { t.since(&t.p.TLS, &t.tlsStart) },
+		GotFirstResponseByte: func() { t.since(&t.p.TTFB, &t.start) },
+	}
+}
+
+func (t *phaseTracer) mark(at *time.Time) {
+	t.mu.Lock()
+	*at = time.Now()
+	t.mu.Unlock()
+}
+
+func (t *phaseTracer) since(phase *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	*phase = time.Since(*start)
+	t.mu.Unlock()
+}
+
+func (t *phaseTracer) phases() Phases {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.p
+}
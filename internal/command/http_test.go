@@ -0,0 +1,130 @@
+package command_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestHTTPExecutor(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cmd, err := command.NewHTTP(server.URL + "/health")
+	if err != nil {
+		t.Fatalf("NewHTTP failed: %v", err)
+	}
+	cmd.Timeout = time.Second
+	for run := 0; run < 3; run++ {
+		if result := cmd.Execute(context.Background()); result.Error != nil || result.Duration <= 0 {
+			t.Fatalf("Run failed: %v, duration %s", result.Error, result.Duration)
+		}
+	}
+	if n := conns.Load(); n != 1 {
+		t.Errorf("Opened %d connections, want one kept alive", n)
+	}
+
+	missing, _ := command.NewHTTP(server.URL + "/missing")
+	if result := missing.Execute(context.Background()); result.ExitCode != http.StatusNotFound || result.Error == nil {
+		t.Errorf("ExitCode = %d, Error = %v, want 404", result.ExitCode, result.Error)
+	}
+}
+
+func TestHTTPExecutorRequest(t *testing.T) {
+	var conns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("X-Test") != "a, b" || string(body) != "payload" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	cmd, err := command.NewHTTPRequest(http.MethodPost, server.URL, command.HTTPOptions{
+		Header:       http.Header{"X-Test": {"a, b"}},
+		Body:         []byte("payload"),
+		NoKeepAlive:  true,
+		ExpectStatus: []int{http.StatusCreated},
+	})
+	if err != nil {
+		t.Fatalf("NewHTTPRequest failed: %v", err)
+	}
+	if want := "POST " + server.URL; cmd.Raw != want {
+		t.Errorf("Raw = %q, want %q", cmd.Raw, want)
+	}
+
+	for run := 0; run < 2; run++ {
+		result := cmd.Execute(context.Background())
+		if result.Error != nil {
+			t.Fatalf("Run failed: %v", result.Error)
+		}
+		// Every run connects without keep-alive
+		if p := result.Phases; p.Connect <= 0 || p.TTFB < p.Connect || p.TTFB > result.Duration {
+			t.Errorf("Phases = %+v, duration %s, want a connect and the first byte within the run", p, result.Duration)
+		}
+	}
+	if n := conns.Load(); n != 2 {
+		t.Errorf("Opened %d connections, want one per run", n)
+	}
+
+	// 200 isn't among the expected statuses
+	unexpected, _ := command.NewHTTPRequest(http.MethodPost, server.URL, command.HTTPOptions{ExpectStatus: []int{http.StatusCreated}})
+	if result := unexpected.Execute(context.Background()); result.ExitCode != http.StatusBadRequest {
+		t.Errorf("ExitCode = %d, want 400", result.ExitCode)
+	}
+}
+
+func TestHTTPExecutorTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+
+	cmd, _ := command.NewHTTP(server.URL)
+	cmd.Timeout = 50 * time.Millisecond
+	if result := cmd.Execute(context.Background()); !result.TimedOut {
+		t.Errorf("TimedOut = false (%v), want a timeout", result.Error)
+	}
+}
+
+func TestNewHTTPInvalid(t *testing.T) {
+	for _, raw := range []string{"localhost:8080", "ftp://example.com", "http://"} {
+		if _, err := command.NewHTTP(raw); err == nil {
+			t.Errorf("NewHTTP(%q) succeeded, want an error", raw)
+		}
+	}
+	if _, err := command.NewHTTPRequest("NOT A METHOD", "http://localhost", command.HTTPOptions{}); err == nil {
+		t.Error("NewHTTPRequest with an invalid method succeeded")
+	}
+}
@@ -0,0 +1,102 @@
+//go:build !windows
+
+package command_test
+
+import (
+	"context"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestKillSignal(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		signal syscall.Signal
+		want   string // Result.Signal
+		stdout string
+	}{
+		{"default", "sleep 5", 0, "SIGKILL", ""},
+		{"terminated", "sleep 5; true", syscall.SIGTERM, "SIGTERM", ""},
+		{"cleaned up", `trap 'echo cleanup; exit 0' TERM; sleep 5 & wait`, syscall.SIGTERM, "", "cleanup\n"},
+		{"ignored", `trap '' TERM; sleep 5; true`, syscall.SIGTERM, "SIGKILL", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := command.NewShell(test.raw, "/bin/sh", []string{"-c"})
+			cmd.Timeout = 200 * time.Millisecond
+			cmd.KillSignal = test.signal
+			cmd.KillGrace = 300 * time.Millisecond
+			cmd.CaptureOutput = true
+
+			start := time.Now()
+			result := cmd.Execute(context.Background())
+			if !result.TimedOut || result.Outcome() != command.OutcomeTimeout {
+				t.Errorf("TimedOut = %t, Outcome = %s, want a timeout", result.TimedOut, result.Outcome())
+			}
+			if result.Signal != test.want {
+				t.Errorf("Signal = %q, want %q", result.Signal, test.want)
+			}
+			if string(result.Stdout) != test.stdout {
+				t.Errorf("Stdout = %q, want %q", result.Stdout, test.stdout)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Took %s, want the run ended by the end of the grace period", elapsed)
+			}
+		})
+	}
+}
+
+func TestPersistentShellKillSignal(t *testing.T) {
+	tests := []struct {
+		name   string
+		raw    string
+		signal syscall.Signal
+		want   string // Result.Signal
+	}{
+		{"default", "sleep 5", 0, "SIGKILL"},
+		{"terminated", "sleep 5", syscall.SIGTERM, "SIGTERM"},
+		{"ignored", "trap : TERM; sleep 5", syscall.SIGTERM, "SIGKILL"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cmd := newPersistent(t, test.raw)
+			cmd.Timeout = 100 * time.Millisecond
+			cmd.KillSignal = test.signal
+			cmd.KillGrace = 300 * time.Millisecond
+
+			start := time.Now()
+			result := cmd.Execute(context.Background())
+			if !result.TimedOut {
+				t.Errorf("TimedOut = false, Error = %v, want a timeout", result.Error)
+			}
+			if result.Signal != test.want {
+				t.Errorf("Signal = %q, want %q", result.Signal, test.want)
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("Took %s, want the shell ended by the end of the grace period", elapsed)
+			}
+		})
+	}
+}
+
+func TestParseSignal(t *testing.T) {
+	for name, want := range map[string]syscall.Signal{
+		"TERM":    syscall.SIGTERM,
+		"SIGTERM": syscall.SIGTERM,
+		"int":     syscall.SIGINT,
+		"9":       syscall.SIGKILL,
+	} {
+		if got, err := command.ParseSignal(name); err != nil || got != want {
+			t.Errorf("ParseSignal(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+	for _, name := range []string{"", "NOPE", "-1"} {
+		if _, err := command.ParseSignal(name); err == nil {
+			t.Errorf("ParseSignal(%q) succeeded", name)
+		}
+	}
+}
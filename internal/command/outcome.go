@@ -0,0 +1,127 @@
+package command
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Outcome is how a run ended
+type Outcome int
+
+const (
+	// OutcomeSuccess is a run that exited with an expected status, see
+	// Command.ExpectExit
+	OutcomeSuccess Outcome = iota
+
+	// OutcomeUnexpectedExit is a run that exited with another status, or an
+	// executor's run that failed otherwise, such as a refused connection
+	OutcomeUnexpectedExit
+
+	// OutcomeSignaled is a run killed by a signal, see Result.Signal
+	OutcomeSignaled
+
+	// OutcomeTimeout is a run killed at the command's timeout
+	OutcomeTimeout
+
+	// OutcomeSpawnFailure is a run that never started
+	OutcomeSpawnFailure
+
+	// OutcomeValidationFailure is a run that succeeded with an unexpected
+	// stdout, see Command.ExpectStdout
+	OutcomeValidationFailure
+
+	// OutcomeCancelled is a run cut short by the end of the benchmark
+	OutcomeCancelled
+)
+
+var outcomeNames = [...]string{
+	OutcomeSuccess:           "success",
+	OutcomeUnexpectedExit:    "unexpected_exit",
+	OutcomeSignaled:          "signal",
+	OutcomeTimeout:           "timeout",
+	OutcomeSpawnFailure:      "spawn_failure",
+	OutcomeValidationFailure: "validation_failure",
+	OutcomeCancelled:         "cancelled",
+}
+
+// String returns the outcome's name, such as "unexpected_exit"
+func (o Outcome) String() string {
+	if o < 0 || int(o) >= len(outcomeNames) {
+		return "Outcome(" + strconv.Itoa(int(o)) + ")"
+	}
+	return outcomeNames[o]
+}
+
+// Failed returns whether a run with the outcome failed; cancelled runs
+// didn't
+func (o Outcome) Failed() bool {
+	return o != OutcomeSuccess && o != OutcomeCancelled
+}
+
+// Outcome classifies how the run ended
+func (r *Result) Outcome() Outcome {
+	switch {
+	case r.SpawnFailed:
+		return OutcomeSpawnFailure
+	case r.TimedOut:
+		return OutcomeTimeout
+	case r.ContextCancelled:
+		return OutcomeCancelled
+	case r.Signal != "":
+		return OutcomeSignaled
+	case r.ValidationFailed:
+		return OutcomeValidationFailure
+	case r.Error != nil:
+		return OutcomeUnexpectedExit
+	}
+	return OutcomeSuccess
+}
+
+// expectedExit returns whether a run exiting with code succeeds
+func (c *Command) expectedExit(code int) bool {
+	if len(c.ExpectExit) == 0 {
+		return code == 0
+	}
+	return slices.Contains(c.ExpectExit, code)
+}
+
+// checkExit fails a run of c that exited with an unexpected status, and
+// clears the error of one that exited with an expected non-zero status
+func (c *Command) checkExit(r *Result) {
+	if r.ExitCode < 0 || r.Signal != "" || r.TimedOut || r.ContextCancelled {
+		return
+	}
+	switch {
+	case c.expectedExit(r.ExitCode):
+		r.Error = nil
+	case len(c.ExpectExit) > 0:
+		r.Error = fmt.Errorf("exit status %d, expected %s", r.ExitCode, FormatExitCodes(c.ExpectExit))
+	case r.Error == nil:
+		r.Error = fmt.Errorf("exit status %d", r.ExitCode)
+	}
+}
+
+// FormatExitCodes formats exit codes as a comma-separated list, as
+// ParseExitCodes reads them
+func FormatExitCodes(codes []int) string {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = strconv.Itoa(code)
+	}
+	return strings.Join(parts, ",")
+}
+
+// ParseExitCodes parses a comma-separated list of exit codes, such as "0,1"
+func ParseExitCodes(s string) ([]int, error) {
+	var codes []int
+	for _, part := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || code < 0 || code > 255 {
+			return nil, fmt.Errorf("invalid exit code %q: use 0 to 255", part)
+		}
+		codes = append(codes, code)
+	}
+	return codes, nil
+}
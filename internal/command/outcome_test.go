@@ -0,0 +1,83 @@
+//go:build !windows
+
+package command_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		raw        string
+		expectExit []int
+		want       command.Outcome
+		signal     string
+	}{
+		{"true", nil, command.OutcomeSuccess, ""},
+		{"exit 3", nil, command.OutcomeUnexpectedExit, ""},
+		{"exit 3", []int{0, 3}, command.OutcomeSuccess, ""},
+		{"true", []int{1}, command.OutcomeUnexpectedExit, ""},
+		{`kill -SEGV $$`, nil, command.OutcomeSignaled, "SIGSEGV"},
+		{`kill -TERM $$`, []int{0, 143}, command.OutcomeSignaled, "SIGTERM"},
+		{"sleep 5", nil, command.OutcomeTimeout, ""},
+	}
+	for _, test := range tests {
+		cmd := command.NewShell(test.raw, "/bin/sh", []string{"-c"})
+		cmd.Timeout = 200 * time.Millisecond
+		cmd.ExpectExit = test.expectExit
+
+		result := cmd.Execute(context.Background())
+		if got := result.Outcome(); got != test.want {
+			t.Errorf("%s expecting %v: Outcome = %s (%v), want %s", test.raw, test.expectExit, got, result.Error, test.want)
+		}
+		if result.Signal != test.signal && test.want != command.OutcomeTimeout {
+			t.Errorf("%s: Signal = %q, want %q", test.raw, result.Signal, test.signal)
+		}
+		if (result.Error == nil) != (test.want == command.OutcomeSuccess) {
+			t.Errorf("%s expecting %v: Error = %v", test.raw, test.expectExit, result.Error)
+		}
+	}
+
+	direct, err := command.NewDirect("/nonexistent/command")
+	if err != nil {
+		t.Fatalf("NewDirect failed: %v", err)
+	}
+	if got := direct.Execute(context.Background()).Outcome(); got != command.OutcomeSpawnFailure {
+		t.Errorf("Missing command: Outcome = %s, want spawn_failure", got)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := command.NewShell("true", "/bin/sh", []string{"-c"}).Execute(ctx).Outcome(); got != command.OutcomeCancelled {
+		t.Errorf("Cancelled run: Outcome = %s, want cancelled", got)
+	}
+}
+
+func TestPersistentShellExpectExit(t *testing.T) {
+	cmd := newPersistent(t, "exit 3")
+	cmd.ExpectExit = []int{3}
+
+	if result := cmd.Execute(context.Background()); result.Outcome() != command.OutcomeSuccess {
+		t.Errorf("Outcome = %s (%v), want success", result.Outcome(), result.Error)
+	}
+}
+
+func TestParseExitCodes(t *testing.T) {
+	codes, err := command.ParseExitCodes("0, 1,255")
+	if err != nil || !slices.Equal(codes, []int{0, 1, 255}) {
+		t.Errorf("ParseExitCodes = %v, %v, want [0 1 255]", codes, err)
+	}
+	if s := command.FormatExitCodes(codes); s != "0,1,255" {
+		t.Errorf("FormatExitCodes = %q, want 0,1,255", s)
+	}
+	for _, s := range []string{"", "1,", "-1", "256", "one"} {
+		if _, err := command.ParseExitCodes(s); err == nil {
+			t.Errorf("ParseExitCodes(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+//go:build !windows
+
+package command
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// PersistentShell runs a command in long-lived shells instead of starting a
+// process for every run, for builtins and shell functions that take less
+// time than a fork and exec. Execute takes an idle shell or starts one, so
+// every worker ends up with a shell of its own. A shell reads the command
+// from a pipe and writes its exit status to another, and a run is timed from
+// writing the one to reading the other.
+//
+// The command runs in the shell itself, so what it does to the shell, such
+// as cd or setting a variable, carries over to the next run. A command that
+// exits ends its shell, and the next run starts another.
+type PersistentShell struct {
+	cmd    *Command
+	script []byte // written to the shell for every run
+
+	mu   sync.Mutex
+	idle []*shellProcess
+}
+
+// shellProcess is one of a PersistentShell's shells
+type shellProcess struct {
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	status chan string // exit statuses, closed once the shell exited
+
+	// mu is held while the shell is reaped, after which its pid may belong
+	// to another process and it is no longer signalled
+	mu     sync.Mutex
+	reaped bool
+}
+
+// NewPersistent creates a command that runs raw in persistent shells, see
+// PersistentShell. shellOptions are passed to the shell except -c, since the
+// shell reads its commands from stdin.
+func NewPersistent(raw, shell string, shellOptions []string) (*Command, error) {
+	c := &Command{
+		Raw:          raw,
+		Shell:        shell,
+		ShellOptions: shellOptions,
+		InShell:      true,
+	}
+	c.Executor = &PersistentShell{cmd: c, script: persistentScript(raw)}
+	return c, nil
+}
+
+// persistentScript evals raw with stdin and the status pipe out of its
+// reach, then reports its exit status on fd 3
+func persistentScript(raw string) []byte {
+	quoted := "'" + strings.ReplaceAll(raw, "'", `'\''`) + "'"
+	return []byte("eval " + quoted + " </dev/null 3>&-\nprintf '%d\\n' \"$?\" >&3\n")
+}
+
+// Execute runs the command once in one of the shells
+func (p *PersistentShell) Execute(ctx context.Context) *Result {
+	result := newResult(p.cmd)
+
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		result.ContextCancelled = true
+		return result
+	}
+
+	sh, err := p.get()
+	if err != nil {
+		result.Error = err
+		result.ExitCode = -1
+		result.SpawnFailed = true
+		result.Duration = time.Since(result.StartTime)
+		return result
+	}
+
+	var timeout <-chan time.Time
+	if p.cmd.Timeout > 0 {
+		timer := time.NewTimer(p.cmd.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	result.StartTime = time.Now()
+	if _, err := sh.stdin.Write(p.script); err != nil {
+		// The shell exited since its last run
+		sh.kill(p.cmd)
+		result.Error = fmt.Errorf("persistent shell: %w", err)
+		result.ExitCode = -1
+		result.Duration = time.Since(result.StartTime)
+		return result
+	}
+
+	select {
+	case status, ok := <-sh.status:
+		result.Duration = time.Since(result.StartTime)
+		if !ok {
+			// The command ended the shell, such as with exit
+			result.ExitCode = sh.cmd.ProcessState.ExitCode()
+			result.Signal = signalName(sh.cmd.ProcessState)
+			if result.Signal != "" {
+				result.Error = fmt.Errorf("persistent shell killed by %s", result.Signal)
+			}
+		} else {
+			p.put(sh)
+			result.ExitCode, _ = strconv.Atoi(status)
+		}
+		p.cmd.checkExit(result)
+	case <-timeout:
+		result.Duration = time.Since(result.StartTime)
+		result.Signal = sh.kill(p.cmd)
+		result.Error = context.DeadlineExceeded
+		result.ExitCode = -1
+		result.TimedOut = true
+	case <-ctx.Done():
+		result.Duration = time.Since(result.StartTime)
+		result.Signal = sh.kill(p.cmd)
+		result.Error = ctx.Err()
+		result.ExitCode = -1
+		result.ContextCancelled = true
+	}
+	return result
+}
+
+// Close ends the idle shells. Runs after it start new ones.
+func (p *PersistentShell) Close() error {
+	p.mu.Lock()
+	idle := p.idle
+	p.idle = nil
+	p.mu.Unlock()
+
+	for _, sh := range idle {
+		// The shell exits at the end of its input
+		_ = sh.stdin.Close()
+		for range sh.status {
+		}
+	}
+	return nil
+}
+
+func (p *PersistentShell) get() (*shellProcess, error) {
+	p.mu.Lock()
+	if n := len(p.idle); n > 0 {
+		sh := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.mu.Unlock()
+		return sh, nil
+	}
+	p.mu.Unlock()
+	return p.start()
+}
+
+func (p *PersistentShell) put(sh *shellProcess) {
+	p.mu.Lock()
+	p.idle = append(p.idle, sh)
+	p.mu.Unlock()
+}
+
+// start starts a shell reading commands from stdin and writing statuses to
+// fd 3
+func (p *PersistentShell) start() (*shellProcess, error) {
+	args := make([]string, 0, len(p.cmd.ShellOptions)+1)
+	for _, opt := range p.cmd.ShellOptions {
+		if opt != "-c" {
+			args = append(args, opt)
+		}
+	}
+	args = append(args, "-s")
+
+	cmd := exec.Command(p.cmd.Shell, args...)
+	setSysProcAttr(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	cmd.ExtraFiles = []*os.File{w}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil, err
+	}
+	w.Close()
+
+	sh := &shellProcess{cmd: cmd, stdin: stdin, status: make(chan string, 1)}
+	go sh.readStatus(r)
+	return sh, nil
+}
+
+// readStatus forwards the shell's exit statuses until it exits
+func (sh *shellProcess) readStatus(r *os.File) {
+	defer close(sh.status)
+	defer r.Close()
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sh.status <- scanner.Text()
+	}
+	sh.mu.Lock()
+	_ = sh.cmd.Wait()
+	sh.reaped = true
+	sh.mu.Unlock()
+}
+
+// signal sends sig to the shell's process group, unless the shell was reaped
+func (sh *shellProcess) signal(sig syscall.Signal) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	if !sh.reaped {
+		_ = syscall.Kill(-sh.cmd.Process.Pid, sig)
+	}
+}
+
+// kill ends the shell and whatever it runs, with the command's KillSignal
+// first if it has one, unless the shell already exited. It returns the name
+// of the signal that ended the shell, or "" if the shell exited on its own.
+func (sh *shellProcess) kill(c *Command) string {
+	exited := make(chan struct{})
+	go func() {
+		for range sh.status {
+		}
+		close(exited)
+	}()
+	terminate(sh.signal, c.KillSignal, c.KillGrace, exited)
+	<-exited
+	// The shell was waited for before its statuses closed
+	return signalName(sh.cmd.ProcessState)
+}
@@ -0,0 +1,116 @@
+//go:build !windows
+
+package command_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func newPersistent(t *testing.T, raw string) *command.Command {
+	t.Helper()
+
+	cmd, err := command.NewPersistent(raw, "/bin/sh", []string{"-c"})
+	if err != nil {
+		t.Fatalf("NewPersistent failed: %v", err)
+	}
+	cmd.Timeout = time.Second
+	t.Cleanup(func() { cmd.Executor.(io.Closer).Close() })
+	return cmd
+}
+
+func TestPersistentShell(t *testing.T) {
+	// The shell and its variables persist between runs
+	cmd := newPersistent(t, `n=$((n+1)); [ "$n" -lt 3 ]`)
+	if !cmd.InShell {
+		t.Error("InShell = false, want true")
+	}
+
+	for run := 1; run <= 3; run++ {
+		result := cmd.Execute(context.Background())
+		if run < 3 && result.Error != nil {
+			t.Fatalf("Run %d failed: %v", run, result.Error)
+		}
+		if run == 3 && result.ExitCode != 1 {
+			t.Errorf("Run %d exit code %d, want 1 from the count of earlier runs", run, result.ExitCode)
+		}
+	}
+}
+
+func TestPersistentShellExitStatus(t *testing.T) {
+	cmd := newPersistent(t, "false")
+
+	result := cmd.Execute(context.Background())
+	if result.ExitCode != 1 || result.Error == nil {
+		t.Errorf("ExitCode = %d, Error = %v, want exit status 1", result.ExitCode, result.Error)
+	}
+}
+
+func TestPersistentShellExit(t *testing.T) {
+	// exit ends the shell, and the next run starts another
+	cmd := newPersistent(t, "exit 3")
+
+	for run := 0; run < 2; run++ {
+		result := cmd.Execute(context.Background())
+		if result.ExitCode != 3 {
+			t.Errorf("ExitCode = %d (%v), want 3", result.ExitCode, result.Error)
+		}
+	}
+}
+
+func TestPersistentShellDiedIdle(t *testing.T) {
+	// The first run has the shell killed once it's idle, so the next run
+	// finds it gone, which ends only that run
+	cmd := newPersistent(t, `[ -n "$started" ] || { started=1; (sleep 0.1; kill -9 $$) & }`)
+
+	for run := 1; run <= 3; run++ {
+		result := cmd.Execute(context.Background())
+		if failed := result.Error != nil; failed != (run == 2) {
+			t.Errorf("Run %d: Error = %v", run, result.Error)
+		}
+		if run == 1 {
+			time.Sleep(300 * time.Millisecond)
+		}
+	}
+}
+
+func TestPersistentShellQuoting(t *testing.T) {
+	cmd := newPersistent(t, `[ 'it'"'"'s' = "it's" ] &&
+[ "$(echo a b)" = 'a b' ]`)
+
+	if result := cmd.Execute(context.Background()); result.Error != nil {
+		t.Errorf("Run failed: %v", result.Error)
+	}
+}
+
+func TestPersistentShellTimeout(t *testing.T) {
+	cmd := newPersistent(t, "sleep 5")
+	cmd.Timeout = 100 * time.Millisecond
+
+	start := time.Now()
+	result := cmd.Execute(context.Background())
+	if !result.TimedOut || result.Error == nil {
+		t.Errorf("TimedOut = %t, Error = %v, want a timeout", result.TimedOut, result.Error)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Took %s, want the run killed at the timeout", elapsed)
+	}
+}
+
+func TestPersistentShellIsFast(t *testing.T) {
+	// A builtin takes far less than starting a process
+	cmd := newPersistent(t, "true")
+
+	cmd.Execute(context.Background())
+	result := cmd.Execute(context.Background())
+	if result.Error != nil {
+		t.Fatalf("Run failed: %v", result.Error)
+	}
+	if result.Duration > 5*time.Millisecond {
+		t.Errorf("Duration = %s, want the shell reused", result.Duration)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package command
+
+import "errors"
+
+// NewPersistent fails on Windows, where cmd.exe can't run commands fed to it
+// one at a time
+func NewPersistent(raw, shell string, shellOptions []string) (*Command, error) {
+	return nil, errors.New("persistent shells are not supported on Windows")
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package command
+
+// DefaultShell and DefaultShellOption run commands when no shell is configured
+const (
+	DefaultShell       = "/bin/sh"
+	DefaultShellOption = "-c"
+)
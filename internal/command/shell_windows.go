@@ -0,0 +1,18 @@
+//go:build windows
+
+package command
+
+import "os"
+
+// DefaultShell and DefaultShellOption run commands when no shell is configured
+var (
+	DefaultShell       = comspec()
+	DefaultShellOption = "/c"
+)
+
+func comspec() string {
+	if shell := os.Getenv("COMSPEC"); shell != "" {
+		return shell
+	}
+	return "cmd.exe"
+}
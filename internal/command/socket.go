@@ -0,0 +1,85 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+// SocketExecutor connects to a TCP or Unix socket for every run. With Send,
+// it writes Send once connected and waits for the first bytes of the
+// response, timing a round trip; without, a run is just the connect.
+type SocketExecutor struct {
+	cmd     *Command
+	Network string
+	Address string
+	Send    []byte
+	dialer  net.Dialer
+}
+
+// NewTCP creates a command that connects to address, a host and port, and
+// sends send if it isn't empty
+func NewTCP(address string, send []byte) (*Command, error) {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return nil, err
+	}
+	return newSocket("tcp", address, send), nil
+}
+
+// NewUnix creates a command that connects to the Unix socket at path, and
+// sends send if it isn't empty
+func NewUnix(path string, send []byte) (*Command, error) {
+	if path == "" {
+		return nil, errors.New("empty socket path")
+	}
+	return newSocket("unix", path, send), nil
+}
+
+func newSocket(network, address string, send []byte) *Command {
+	c := &Command{Raw: address}
+	c.Executor = &SocketExecutor{cmd: c, Network: network, Address: address, Send: send}
+	return c
+}
+
+func (e *SocketExecutor) Execute(ctx context.Context) *Result {
+	result := newResult(e.cmd)
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		result.ContextCancelled = true
+		return result
+	}
+
+	execCtx, cancel := withTimeout(ctx, e.cmd.Timeout)
+	defer cancel()
+
+	startTime := time.Now()
+	err := e.roundTrip(execCtx)
+	result.Duration = time.Since(startTime)
+	if err != nil {
+		result.fail(ctx, execCtx, err)
+	}
+	return result
+}
+
+func (e *SocketExecutor) roundTrip(ctx context.Context) error {
+	conn, err := e.dialer.DialContext(ctx, e.Network, e.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if len(e.Send) == 0 {
+		return nil
+	}
+
+	// Unblock the write and read once ctx is done
+	stop := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	if _, err := conn.Write(e.Send); err != nil {
+		return err
+	}
+	var buf [512]byte
+	_, err = conn.Read(buf[:])
+	return err
+}
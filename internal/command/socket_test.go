@@ -0,0 +1,95 @@
+package command_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// echoServer echoes what it receives on every connection to listener
+func echoServer(t *testing.T, listener net.Listener) {
+	t.Helper()
+	t.Cleanup(func() { listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 512)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					conn.Write(buf[:n])
+				}
+			}()
+		}
+	}()
+}
+
+func TestSocketExecutorTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	echoServer(t, listener)
+
+	for _, send := range [][]byte{nil, []byte("PING\r\n")} {
+		cmd, err := command.NewTCP(listener.Addr().String(), send)
+		if err != nil {
+			t.Fatalf("NewTCP failed: %v", err)
+		}
+		cmd.Timeout = time.Second
+		if result := cmd.Execute(context.Background()); result.Error != nil || result.Duration <= 0 {
+			t.Errorf("Send %q: run failed: %v, duration %s", send, result.Error, result.Duration)
+		}
+	}
+
+	listener.Close()
+	cmd, _ := command.NewTCP(listener.Addr().String(), nil)
+	if result := cmd.Execute(context.Background()); result.Error == nil {
+		t.Error("Connecting to a closed listener succeeded")
+	}
+}
+
+func TestSocketExecutorUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "echo.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	echoServer(t, listener)
+
+	cmd, err := command.NewUnix(path, []byte("ping"))
+	if err != nil {
+		t.Fatalf("NewUnix failed: %v", err)
+	}
+	cmd.Timeout = time.Second
+	if result := cmd.Execute(context.Background()); result.Error != nil {
+		t.Errorf("Run failed: %v", result.Error)
+	}
+}
+
+func TestSocketExecutorTimeout(t *testing.T) {
+	// A listener that never answers
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen failed: %v", err)
+	}
+	defer listener.Close()
+
+	cmd, _ := command.NewTCP(listener.Addr().String(), []byte("hello"))
+	cmd.Timeout = 50 * time.Millisecond
+	if result := cmd.Execute(context.Background()); !result.TimedOut {
+		t.Errorf("TimedOut = false (%v), want a timeout", result.Error)
+	}
+}
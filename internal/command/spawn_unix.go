@@ -3,8 +3,15 @@
 package command
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
 )
 
 func setSysProcAttr(cmd *exec.Cmd) {
@@ -14,3 +21,57 @@ func setSysProcAttr(cmd *exec.Cmd) {
 func killProcessGroup(pid int) {
 	_ = syscall.Kill(-pid, syscall.SIGKILL)
 }
+
+// terminateProcessGroup sends sig to the process group of pid, then kills it
+// if done isn't closed within grace, and returns the last signal it sent. A
+// zero sig kills it right away.
+func terminateProcessGroup(pid int, sig syscall.Signal, grace time.Duration, done <-chan struct{}) syscall.Signal {
+	return terminate(func(sig syscall.Signal) { _ = syscall.Kill(-pid, sig) }, sig, grace, done)
+}
+
+// terminate is terminateProcessGroup with the signals sent by signal
+func terminate(signal func(syscall.Signal), sig syscall.Signal, grace time.Duration, done <-chan struct{}) syscall.Signal {
+	if sig == 0 || sig == syscall.SIGKILL {
+		signal(syscall.SIGKILL)
+		return syscall.SIGKILL
+	}
+	signal(sig)
+	timer := time.NewTimer(grace)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		signal(syscall.SIGKILL)
+		return syscall.SIGKILL
+	case <-done:
+		return sig
+	}
+}
+
+// ParseSignal parses a signal name, with or without SIG, such as TERM or
+// SIGINT, or its number
+func ParseSignal(name string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(name); err == nil && n > 0 {
+		return syscall.Signal(n), nil
+	}
+	upper := strings.ToUpper(name)
+	if !strings.HasPrefix(upper, "SIG") {
+		upper = "SIG" + upper
+	}
+	if sig := unix.SignalNum(upper); sig != 0 {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
+
+// signalName returns the name of the signal that killed a process, such as
+// SIGKILL, or "" if it exited
+func signalName(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+	if name := unix.SignalName(status.Signal()); name != "" {
+		return name
+	}
+	return "signal " + strconv.Itoa(int(status.Signal()))
+}
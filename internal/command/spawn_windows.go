@@ -3,8 +3,13 @@
 package command
 
 import (
+	"fmt"
+	"os"
 	"os/exec"
 	"strconv"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func setSysProcAttr(cmd *exec.Cmd) {}
@@ -15,3 +20,24 @@ func setSysProcAttr(cmd *exec.Cmd) {}
 func killProcessGroup(pid int) {
 	_ = exec.Command("taskkill", "/F", "/T", "/PID", strconv.Itoa(pid)).Run()
 }
+
+// terminateProcessGroup kills the process tree of pid, since Windows has no
+// signals to ask it to exit first
+func terminateProcessGroup(pid int, sig syscall.Signal, grace time.Duration, done <-chan struct{}) syscall.Signal {
+	killProcessGroup(pid)
+	return syscall.SIGKILL
+}
+
+// ParseSignal only accepts KILL, since Windows has no other signals to send
+func ParseSignal(name string) (syscall.Signal, error) {
+	switch strings.ToUpper(name) {
+	case "KILL", "SIGKILL", "9":
+		return syscall.SIGKILL, nil
+	}
+	return 0, fmt.Errorf("signal %q isn't supported on Windows, only KILL", name)
+}
+
+// signalName returns "", since Windows has no signals
+func signalName(state *os.ProcessState) string {
+	return ""
+}
@@ -0,0 +1,57 @@
+package command
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Stdin is the input fed to every run of a command
+type Stdin struct {
+	// Path is the file holding the input
+	Path string
+
+	// Size of the input in bytes
+	Size int64
+
+	// Pipe writes the input to the command through a pipe, so that a run
+	// includes writing it. Otherwise the command reads the file itself, as
+	// with `command < file`.
+	Pipe bool
+
+	data []byte // the input, with Pipe
+}
+
+// NewStdin returns the input in the file at path, which pipe reads into
+// memory to write from
+func NewStdin(path string, pipe bool) (*Stdin, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("input %s is not a regular file", path)
+	}
+
+	in := &Stdin{Path: path, Size: info.Size(), Pipe: pipe}
+	if pipe {
+		if in.data, err = os.ReadFile(path); err != nil {
+			return nil, err
+		}
+		in.Size = int64(len(in.data))
+	}
+	return in, nil
+}
+
+// open returns the stdin of a run, to close once it is done
+func (in *Stdin) open() (io.Reader, func(), error) {
+	if in.Pipe {
+		return bytes.NewReader(in.data), func() {}, nil
+	}
+	f, err := os.Open(in.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
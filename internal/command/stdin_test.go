@@ -0,0 +1,52 @@
+//go:build !windows
+
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestStdin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	data := bytes.Repeat([]byte("cmdperf\n"), 1<<15)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	for _, pipe := range []bool{false, true} {
+		stdin, err := command.NewStdin(path, pipe)
+		if err != nil {
+			t.Fatalf("NewStdin(pipe %t) failed: %v", pipe, err)
+		}
+		if stdin.Size != int64(len(data)) {
+			t.Errorf("Size = %d, want %d", stdin.Size, len(data))
+		}
+
+		// Every run reads the whole input, and commands may leave it unread
+		for _, raw := range []string{fmt.Sprintf(`[ "$(wc -c)" -eq %d ]`, len(data)), "true"} {
+			cmd := command.NewShell(raw, "/bin/sh", []string{"-c"})
+			cmd.Timeout = 5 * time.Second
+			cmd.Stdin = stdin
+			for run := 0; run < 2; run++ {
+				if result := cmd.Execute(context.Background()); result.Error != nil {
+					t.Errorf("%s (pipe %t) run %d failed: %v", raw, pipe, run, result.Error)
+				}
+			}
+		}
+	}
+
+	if _, err := command.NewStdin(t.TempDir(), false); err == nil {
+		t.Error("NewStdin of a directory succeeded")
+	}
+	if _, err := command.NewStdin(filepath.Join(t.TempDir(), "missing"), false); err == nil {
+		t.Error("NewStdin of a missing file succeeded")
+	}
+}
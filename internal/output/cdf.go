@@ -0,0 +1,45 @@
+package output
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+// CDFPoints is the default resolution of the CDF, percentiles 0, 0.1, ... 100
+const CDFPoints = 1000
+
+// CDFWriter writes the latency distribution of every command as CSV rows of
+// command, percentile and latency, at a fixed resolution of Points (CDFPoints
+// when zero) so that distributions plot and compare alike
+type CDFWriter struct {
+	Points int
+}
+
+func (w *CDFWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) error {
+	points := w.Points
+	if points <= 0 {
+		points = CDFPoints
+	}
+
+	csvWriter := csv.NewWriter(writer)
+	if err := csvWriter.Write([]string{"Command", "Percentile", "Latency (ns)"}); err != nil {
+		return fmt.Errorf("failed to write CDF header: %w", err)
+	}
+	for _, stat := range stats {
+		for _, p := range stat.CDF(points) {
+			row := []string{stat.Command.Raw, benchmark.FormatPercentile(p.P), fmt.Sprintf("%d", p.Value.Nanoseconds())}
+			if err := csvWriter.Write(row); err != nil {
+				return fmt.Errorf("failed to write CDF for command '%s': %w", stat.Command.Raw, err)
+			}
+		}
+	}
+
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("error flushing CDF data: %w", err)
+	}
+	return nil
+}
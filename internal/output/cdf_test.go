@@ -0,0 +1,28 @@
+package output
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCDFWriter(t *testing.T) {
+	stats := createTestStats()
+	stats[0].MedianSamples = []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond, 4 * time.Millisecond}
+
+	var buf bytes.Buffer
+	if err := (&CDFWriter{Points: 4}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write CDF: %v", err)
+	}
+
+	want := `Command,Percentile,Latency (ns)
+echo hello,0,1000000
+echo hello,25,2000000
+echo hello,50,3000000
+echo hello,75,4000000
+echo hello,100,4000000
+`
+	if got := buf.String(); got != want {
+		t.Errorf("CDF =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/miklosn/cmdperf/internal/benchmark"
 )
@@ -27,9 +28,17 @@ func (w *CSVWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) err
 		"StdDev (ns)",
 		"Throughput (/s)",
 		"TargetRate (/s)",
-		"P50 (ns)",
-		"P95 (ns)",
-		"P99 (ns)",
+	}
+	// The percentiles requested, which are the same for every command
+	var percentiles []benchmark.Percentile
+	if len(stats) > 0 {
+		percentiles = Percentiles(stats[0])
+	}
+	for _, p := range percentiles {
+		header = append(header, fmt.Sprintf("P%s (ns)", benchmark.FormatPercentile(p.P)))
+	}
+	for _, group := range csvGroups {
+		header = append(header, group.header...)
 	}
 	if err := csvWriter.Write(header); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
@@ -56,9 +65,20 @@ func (w *CSVWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) err
 			fmt.Sprintf("%d", stat.StdDev.Nanoseconds()),
 			fmt.Sprintf("%f", stat.Throughput),
 			fmt.Sprintf("%f", stat.TargetRate),
-			fmt.Sprintf("%d", stat.P50.Nanoseconds()),
-			fmt.Sprintf("%d", stat.P95.Nanoseconds()),
-			fmt.Sprintf("%d", stat.P99.Nanoseconds()),
+		}
+		values := make(map[float64]time.Duration)
+		for _, p := range Percentiles(stat) {
+			values[p.P] = p.Value
+		}
+		for _, p := range percentiles {
+			row = append(row, fmt.Sprintf("%d", values[p.P].Nanoseconds()))
+		}
+		for _, group := range csvGroups {
+			cells := group.cells(stat)
+			if cells == nil {
+				cells = make([]string, len(group.header))
+			}
+			row = append(row, cells...)
 		}
 		if err := csvWriter.Write(row); err != nil {
 			return fmt.Errorf("failed to write CSV row for command '%s': %w", stat.Command.Raw, err)
@@ -72,3 +92,76 @@ func (w *CSVWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) err
 
 	return nil
 }
+
+// csvGroups are the columns after the percentiles, in order. A group's cells
+// are nil, written blank, for commands it doesn't apply to.
+var csvGroups = []struct {
+	header []string
+	cells  func(stat *benchmark.CommandStats) []string
+}{
+	{
+		[]string{"Outliers", "SevereOutliers", "OutlierVarianceShare"},
+		func(stat *benchmark.CommandStats) []string {
+			o := stat.Outliers
+			if o == nil {
+				return nil
+			}
+			return []string{
+				fmt.Sprintf("%d", o.Total()),
+				fmt.Sprintf("%d", o.Severe()),
+				fmt.Sprintf("%f", o.VarianceShare),
+			}
+		},
+	},
+	{
+		[]string{"Trend", "SteadyFromRun", "DriftingAtEnd"},
+		func(stat *benchmark.CommandStats) []string {
+			d := stat.Drift
+			if d == nil {
+				return nil
+			}
+			return []string{
+				fmt.Sprintf("%f", d.Trend),
+				fmt.Sprintf("%d", d.SteadyFrom),
+				fmt.Sprintf("%t", d.DriftingAtEnd),
+			}
+		},
+	},
+	{
+		[]string{"ShellOverhead (ns)", "MostlyShellOverhead"},
+		func(stat *benchmark.CommandStats) []string {
+			o := stat.ShellOverhead
+			if o == nil {
+				return nil
+			}
+			return []string{
+				fmt.Sprintf("%d", o.Mean.Nanoseconds()),
+				fmt.Sprintf("%t", stat.MostlyShellOverhead),
+			}
+		},
+	},
+	{
+		// MB/s in the decimal megabytes of FormatInput
+		[]string{"InputBytes", "Input (MB/s)"},
+		func(stat *benchmark.CommandStats) []string {
+			if stat.InputBytes == 0 {
+				return nil
+			}
+			return []string{
+				fmt.Sprintf("%d", stat.InputBytes),
+				fmt.Sprintf("%f", stat.InputThroughput/1e6),
+			}
+		},
+	},
+	{
+		[]string{"UnexpectedExits", "Signaled", "Timeouts", "SpawnFailures", "ValidationFailures", "Cancelled"},
+		func(stat *benchmark.CommandStats) []string {
+			o := stat.Outcomes
+			var cells []string
+			for _, count := range []int{o.UnexpectedExit, o.Signaled, o.Timeout, o.SpawnFailure, o.ValidationFailure, o.Cancelled} {
+				cells = append(cells, fmt.Sprintf("%d", count))
+			}
+			return cells
+		},
+	},
+}
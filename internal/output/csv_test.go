@@ -2,8 +2,12 @@ package output
 
 import (
 	"bytes"
+	"encoding/csv"
+	"slices"
 	"strings"
 	"testing"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
 )
 
 func TestCSVWriter(t *testing.T) {
@@ -33,3 +37,53 @@ func TestCSVWriter(t *testing.T) {
 		t.Errorf("Expected 3 lines (header + 2 commands), got %d", len(lines))
 	}
 }
+
+func TestCSVWriterPercentiles(t *testing.T) {
+	stats := createTestStats()
+	for _, stat := range stats {
+		stat.Percentiles = []benchmark.Percentile{{P: 50, Value: stat.Median}, {P: 99.9, Value: stat.Max}}
+	}
+
+	var buf bytes.Buffer
+	if err := (&CSVWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+	header := strings.Join(records[0], ",")
+	if !strings.Contains(header, "TargetRate (/s),P50 (ns),P99.9 (ns),Outliers") {
+		t.Errorf("Header %q lacks the requested percentiles", header)
+	}
+	if got := records[1][13]; got != "5000000" {
+		t.Errorf("P99.9 = %s, want 5000000", got)
+	}
+}
+
+func TestCSVWriterInput(t *testing.T) {
+	stats := createTestStats()
+	stats[0].InputBytes = 1500000
+	stats[0].InputThroughput = 250e6
+
+	var buf bytes.Buffer
+	if err := (&CSVWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write CSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("Invalid CSV: %v", err)
+	}
+
+	column := slices.Index(records[0], "InputBytes")
+	if column < 0 || records[0][column+1] != "Input (MB/s)" {
+		t.Fatalf("Header %v lacks InputBytes and Input (MB/s)", records[0])
+	}
+	if got := records[1][column : column+2]; got[0] != "1500000" || got[1] != "250.000000" {
+		t.Errorf("Input columns = %v, want 1500000 and 250.000000", got)
+	}
+	if got := records[2][column : column+2]; got[0] != "" || got[1] != "" {
+		t.Errorf("Input columns = %v without input, want blanks", got)
+	}
+}
@@ -0,0 +1,71 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+// EventWriter writes benchmark events as newline-delimited JSON, one object
+// per event with its type under "type". Each event is written with a single
+// Write call, so a reader following the file (e.g. with tail -f) only ever
+// sees whole lines.
+type EventWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	err error
+}
+
+// NewEventWriter creates an EventWriter writing to w
+func NewEventWriter(w io.Writer) *EventWriter {
+	return &EventWriter{w: w}
+}
+
+// Handle writes event. It is safe for concurrent use, as required by
+// Runner.SetEventHandler; the first error stops further writes and is
+// returned by Err.
+func (e *EventWriter) Handle(event benchmark.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.err != nil {
+		return
+	}
+
+	line, err := marshalEvent(event)
+	if err != nil {
+		e.err = err
+		return
+	}
+	_, e.err = e.w.Write(line)
+}
+
+// Err returns the first error encountered while writing events
+func (e *EventWriter) Err() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.err
+}
+
+// marshalEvent encodes event as a JSON line with "type" as its first key
+func marshalEvent(event benchmark.Event) ([]byte, error) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	eventType, err := json.Marshal(event.EventType())
+	if err != nil {
+		return nil, err
+	}
+
+	line := make([]byte, 0, len(data)+len(eventType)+10)
+	line = append(line, `{"type":`...)
+	line = append(line, eventType...)
+	if len(data) > 2 {
+		line = append(line, ',')
+	}
+	line = append(line, data[1:]...)
+	return append(line, '\n'), nil
+}
@@ -0,0 +1,51 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+func TestEventWriter(t *testing.T) {
+	var buf bytes.Buffer
+	events := NewEventWriter(&buf)
+
+	events.Handle(&benchmark.CommandStarted{Index: 1, Command: "echo hello"})
+	events.Handle(&benchmark.CommandCompleted{
+		Index:   1,
+		Elapsed: time.Second,
+		Stats:   benchmark.Snapshot{Command: "echo hello", TotalRuns: 5, Mean: 2 * time.Millisecond},
+	})
+	if err := events.Err(); err != nil {
+		t.Fatalf("Handle failed: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+	if !strings.HasPrefix(lines[0], `{"type":"command_started","time":`) {
+		t.Errorf("Type is not the first key: %s", lines[0])
+	}
+
+	var completed struct {
+		Type    string `json:"type"`
+		Index   int    `json:"command_index"`
+		Elapsed int64  `json:"elapsed_ns"`
+		Stats   struct {
+			TotalRuns int   `json:"total_runs"`
+			MeanNs    int64 `json:"mean_ns"`
+		} `json:"stats"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &completed); err != nil {
+		t.Fatalf("Invalid JSON line %q: %v", lines[1], err)
+	}
+	if completed.Type != "command_completed" || completed.Index != 1 || completed.Elapsed != int64(time.Second) ||
+		completed.Stats.TotalRuns != 5 || completed.Stats.MeanNs != int64(2*time.Millisecond) {
+		t.Errorf("Unexpected event: %+v", completed)
+	}
+}
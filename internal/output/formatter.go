@@ -2,6 +2,8 @@ package output
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/miklosn/cmdperf/internal/benchmark"
@@ -38,6 +40,129 @@ func FormatThroughput(throughput float64) string {
 	}
 }
 
+// FormatBytes formats a number of bytes in decimal units, such as "1.50 MB"
+func FormatBytes(bytes float64) string {
+	if bytes >= 1e9 {
+		return fmt.Sprintf("%.2f GB", bytes/1e9)
+	} else if bytes >= 1e6 {
+		return fmt.Sprintf("%.2f MB", bytes/1e6)
+	} else if bytes >= 1e3 {
+		return fmt.Sprintf("%.2f KB", bytes/1e3)
+	} else {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+}
+
+// FormatInput describes the input fed to a command, such as
+// "10.00 MB per run, 152.30 MB/s", or returns "" without input
+func FormatInput(stat *benchmark.CommandStats) string {
+	if stat.InputBytes == 0 {
+		return ""
+	}
+	input := FormatBytes(float64(stat.InputBytes)) + " per run"
+	if stat.InputThroughput > 0 {
+		input += ", " + FormatBytes(stat.InputThroughput) + "/s"
+	}
+	return input
+}
+
+// FormatValidation describes a command's runs that failed --expect-stdout,
+// such as "3 of 100 runs: stdout doesn't match ^ok$", or returns "" without
+// any
+func FormatValidation(stat *benchmark.CommandStats) string {
+	if stat.Outcomes.ValidationFailure == 0 || stat.Command.ExpectStdout == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d runs: stdout doesn't match %s",
+		stat.Outcomes.ValidationFailure, stat.TotalRuns, stat.Command.ExpectStdout.Spec)
+}
+
+// FormatOutcomes describes how a command's runs failed, such as
+// "2 unexpected exits (exit 1: 2), 1 killed by a signal (SIGSEGV: 1),
+// 3 timed out", or returns "" if none did
+func FormatOutcomes(o benchmark.Outcomes) string {
+	var parts []string
+	add := func(count int, one, many, detail string) {
+		if count == 0 {
+			return
+		}
+		part := fmt.Sprintf("%d %s", count, many)
+		if count == 1 {
+			part = "1 " + one
+		}
+		if detail != "" {
+			part += " (" + detail + ")"
+		}
+		parts = append(parts, part)
+	}
+
+	codes := make([]int, 0, len(o.ExitCodes))
+	for code := range o.ExitCodes {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	exits := make([]string, len(codes))
+	for i, code := range codes {
+		exits[i] = fmt.Sprintf("exit %d: %d", code, o.ExitCodes[code])
+	}
+	signals := make([]string, 0, len(o.Signals))
+	for signal, count := range o.Signals {
+		signals = append(signals, fmt.Sprintf("%s: %d", signal, count))
+	}
+	sort.Strings(signals)
+
+	add(o.UnexpectedExit, "unexpected exit", "unexpected exits", strings.Join(exits, ", "))
+	add(o.Signaled, "killed by a signal", "killed by signals", strings.Join(signals, ", "))
+	add(o.Timeout, "timed out", "timed out", "")
+	add(o.SpawnFailure, "failed to start", "failed to start", "")
+	add(o.ValidationFailure, "failed validation", "failed validation", "")
+	if len(parts) > 0 {
+		add(o.Cancelled, "cancelled", "cancelled", "")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// FormatOutcomesShort summarizes how a command's runs failed in a few words
+// for progress displays, such as "2 exit, 1 SIGSEGV, 3 timeout", or returns
+// "" if none did
+func FormatOutcomesShort(o benchmark.Outcomes) string {
+	var parts []string
+	if o.UnexpectedExit > 0 {
+		parts = append(parts, fmt.Sprintf("%d exit", o.UnexpectedExit))
+	}
+	signals := make([]string, 0, len(o.Signals))
+	for signal := range o.Signals {
+		signals = append(signals, signal)
+	}
+	sort.Strings(signals)
+	for _, signal := range signals {
+		parts = append(parts, fmt.Sprintf("%d %s", o.Signals[signal], signal))
+	}
+	for _, count := range []struct {
+		n    int
+		name string
+	}{{o.Timeout, "timeout"}, {o.SpawnFailure, "spawn"}, {o.ValidationFailure, "invalid"}} {
+		if count.n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", count.n, count.name))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// OutputExcerpt returns up to maxLines lines of a run's captured output,
+// noting the lines it leaves out
+func OutputExcerpt(output string, maxLines int) []string {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	if len(lines) == 1 && lines[0] == "" {
+		return []string{"(empty)"}
+	}
+	if len(lines) > maxLines {
+		more := len(lines) - maxLines
+		lines = append(lines[:maxLines], fmt.Sprintf("… %d more lines", more))
+	}
+	return lines
+}
+
 // FormatThroughputWithRate formats throughput and includes rate information if available
 func FormatThroughputWithRate(throughput, targetRate float64) string {
 	// Format the base throughput
@@ -59,17 +184,93 @@ func GetThroughputHeader(stats []*benchmark.CommandStats) string {
 	return "Throughput"
 }
 
-// HasNonZeroExitCodes checks if a command has any non-zero exit codes
-func HasNonZeroExitCodes(stat *benchmark.CommandStats) bool {
-	if stat == nil {
-		return false
+// VarianceAdvice suggests what to do about stat's high variance: when the
+// distribution has several modes or outliers cause most of it, more runs
+// won't help
+func VarianceAdvice(stat *benchmark.CommandStats) string {
+	if stat.Modes.Multimodal() {
+		return "It comes from the modes; more runs won't reduce it."
+	}
+	if o := stat.Outliers; o != nil && !o.Dropped && o.Total() > 0 && o.VarianceShare > 0.5 {
+		return fmt.Sprintf("%.0f%% of it is from %d outliers, likely interference; try --outliers=drop.",
+			o.VarianceShare*100, o.Total())
+	}
+	return "Try more runs."
+}
+
+// Percentiles returns the percentiles to report for stat: the requested
+// ones, or P50, P95 and P99 for statistics that don't have them
+func Percentiles(stat *benchmark.CommandStats) []benchmark.Percentile {
+	if len(stat.Percentiles) > 0 {
+		return stat.Percentiles
+	}
+	return []benchmark.Percentile{{P: 50, Value: stat.P50}, {P: 95, Value: stat.P95}, {P: 99, Value: stat.P99}}
+}
+
+var phaseLabels = map[string]string{
+	benchmark.PhaseDNS:     "DNS",
+	benchmark.PhaseConnect: "connect",
+	benchmark.PhaseTLS:     "TLS",
+	benchmark.PhaseTTFB:    "TTFB",
+	benchmark.PhaseTotal:   "total",
+}
+
+// FormatPhases formats the mean of every phase of a command's HTTP requests,
+// with the runs that went through a phase if not all did, such as
+// "DNS 52.00 µs (2 runs), connect 90.00 µs (2 runs), TTFB 1.10 ms, total 1.20 ms"
+func FormatPhases(phases []benchmark.Phase) string {
+	if len(phases) == 0 {
+		return ""
 	}
+	all := phases[len(phases)-1].Runs
+	parts := make([]string, len(phases))
+	for i, phase := range phases {
+		parts[i] = fmt.Sprintf("%s %s", phaseLabels[phase.Name], FormatDuration(phase.Mean))
+		switch {
+		case phase.Runs == 1 && all > 1:
+			parts[i] += " (1 run)"
+		case phase.Runs < all:
+			parts[i] += fmt.Sprintf(" (%d runs)", phase.Runs)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
 
-	for exitCode, count := range stat.ExitCodes {
-		if exitCode != 0 && count > 0 {
-			return true
+// ShellOverheads describes the overhead of every shell among stats, once per
+// shell, such as "Shell overhead (/bin/sh -c): ~1.02 ms ± 95.00 µs"
+func ShellOverheads(stats []*benchmark.CommandStats) []string {
+	var lines []string
+	seen := make(map[*benchmark.ShellOverhead]bool)
+	for _, stat := range stats {
+		if stat == nil || stat.ShellOverhead == nil || seen[stat.ShellOverhead] {
+			continue
 		}
+		o := stat.ShellOverhead
+		seen[o] = true
+		line := fmt.Sprintf("Shell overhead (%s): ~%s ± %s", o.Shell, FormatDuration(o.Mean), FormatDuration(o.StdDev))
+		if o.Subtracted {
+			line += ", subtracted from every run"
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// InShellNote labels a command measured in a persistent shell, or returns ""
+// for other commands
+func InShellNote(stat *benchmark.CommandStats) string {
+	if !stat.Command.InShell {
+		return ""
 	}
+	return fmt.Sprintf("Measured in-shell: runs share a persistent %s per worker, without starting a process", stat.Command.Shell)
+}
 
-	return false
+// ShellOverheadWarning warns about a command whose own time is within the
+// noise of its shell's startup, or returns "" for other commands
+func ShellOverheadWarning(stat *benchmark.CommandStats) string {
+	if !stat.MostlyShellOverhead || stat.ShellOverhead == nil {
+		return ""
+	}
+	return fmt.Sprintf("⚠ Mostly shell startup: the command takes about as long as an empty one through %s. Try --no-shell.",
+		stat.ShellOverhead.Shell)
 }
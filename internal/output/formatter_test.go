@@ -1,8 +1,11 @@
 package output
 
 import (
+	"slices"
 	"testing"
 	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
 )
 
 func TestFormatDuration(t *testing.T) {
@@ -49,3 +52,70 @@ func TestFormatThroughput(t *testing.T) {
 		}
 	}
 }
+
+func TestFormatPhases(t *testing.T) {
+	phases := []benchmark.Phase{
+		{Name: benchmark.PhaseDNS, Mean: 50 * time.Microsecond, Runs: 1},
+		{Name: benchmark.PhaseConnect, Mean: 90 * time.Microsecond, Runs: 2},
+		{Name: benchmark.PhaseTTFB, Mean: 1100 * time.Microsecond, Runs: 10},
+		{Name: benchmark.PhaseTotal, Mean: 1200 * time.Microsecond, Runs: 10},
+	}
+	want := "DNS 50.00 µs (1 run), connect 90.00 µs (2 runs), TTFB 1.10 ms, total 1.20 ms"
+	if got := FormatPhases(phases); got != want {
+		t.Errorf("FormatPhases = %q, want %q", got, want)
+	}
+	if got := FormatPhases(nil); got != "" {
+		t.Errorf("FormatPhases(nil) = %q, want none", got)
+	}
+}
+
+func TestFormatInput(t *testing.T) {
+	stat := &benchmark.CommandStats{InputBytes: 1500000, InputThroughput: 250e6}
+	if got, want := FormatInput(stat), "1.50 MB per run, 250.00 MB/s"; got != want {
+		t.Errorf("FormatInput = %q, want %q", got, want)
+	}
+	if got := FormatInput(&benchmark.CommandStats{}); got != "" {
+		t.Errorf("FormatInput without input = %q, want none", got)
+	}
+	if got, want := FormatBytes(512), "512 B"; got != want {
+		t.Errorf("FormatBytes(512) = %q, want %q", got, want)
+	}
+}
+
+func TestOutputExcerpt(t *testing.T) {
+	tests := []struct {
+		output string
+		want   []string
+	}{
+		{"", []string{"(empty)"}},
+		{"one\n", []string{"one"}},
+		{"1\n2\n3\n4\n", []string{"1", "2", "… 2 more lines"}},
+	}
+	for _, test := range tests {
+		if got := OutputExcerpt(test.output, 2); !slices.Equal(got, test.want) {
+			t.Errorf("OutputExcerpt(%q) = %q, want %q", test.output, got, test.want)
+		}
+	}
+}
+
+func TestFormatOutcomes(t *testing.T) {
+	outcomes := benchmark.Outcomes{
+		Success:        90,
+		UnexpectedExit: 3,
+		Signaled:       1,
+		Timeout:        2,
+		Cancelled:      1,
+		ExitCodes:      map[int]int{2: 1, 1: 2},
+		Signals:        map[string]int{"SIGSEGV": 1},
+	}
+	want := "3 unexpected exits (exit 1: 2, exit 2: 1), 1 killed by a signal (SIGSEGV: 1), 2 timed out, 1 cancelled"
+	if got := FormatOutcomes(outcomes); got != want {
+		t.Errorf("FormatOutcomes = %q, want %q", got, want)
+	}
+	if got, want := FormatOutcomesShort(outcomes), "3 exit, 1 SIGSEGV, 2 timeout"; got != want {
+		t.Errorf("FormatOutcomesShort = %q, want %q", got, want)
+	}
+	if got := FormatOutcomes(benchmark.Outcomes{Success: 10, Cancelled: 1}); got != "" {
+		t.Errorf("FormatOutcomes without failures = %q, want none", got)
+	}
+}
@@ -0,0 +1,170 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+// GitHubActions reports results to a GitHub Actions job: a Markdown step
+// summary, workflow command annotations and step outputs.
+// See https://docs.github.com/en/actions/using-workflows/workflow-commands-for-github-actions
+type GitHubActions struct {
+	// SummaryPath is the file the Markdown summary is appended to ($GITHUB_STEP_SUMMARY)
+	SummaryPath string
+
+	// OutputPath is the file step outputs are appended to ($GITHUB_OUTPUT)
+	OutputPath string
+
+	// Thresholds turn into error annotations when violated
+	Thresholds benchmark.Thresholds
+}
+
+// InGitHubActions reports whether cmdperf is running inside a GitHub Actions job
+func InGitHubActions() bool {
+	return os.Getenv("GITHUB_ACTIONS") == "true"
+}
+
+// GitHubActionsFromEnv returns a reporter writing to the files named by the
+// job's environment. Missing variables leave the corresponding path empty.
+func GitHubActionsFromEnv() *GitHubActions {
+	return &GitHubActions{
+		SummaryPath: os.Getenv("GITHUB_STEP_SUMMARY"),
+		OutputPath:  os.Getenv("GITHUB_OUTPUT"),
+	}
+}
+
+// Write emits annotations to writer and appends the step summary and outputs
+// to their files, skipping any whose path is empty.
+func (g *GitHubActions) Write(writer io.Writer, stats []*benchmark.CommandStats) error {
+	for _, stat := range stats {
+		g.writeAnnotations(writer, stat)
+	}
+
+	if g.SummaryPath != "" {
+		var buf bytes.Buffer
+		if err := (&MarkdownWriter{}).Write(&buf, stats); err != nil {
+			return err
+		}
+		if err := appendToFile(g.SummaryPath, buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to write step summary: %w", err)
+		}
+	}
+
+	if g.OutputPath != "" {
+		if err := appendToFile(g.OutputPath, []byte(formatStepOutputs(stats))); err != nil {
+			return fmt.Errorf("failed to write step outputs: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *GitHubActions) writeAnnotations(writer io.Writer, stat *benchmark.CommandStats) {
+	cmd := stat.Command.Raw
+
+	if stat.HighVariance && stat.Mean > 0 {
+		pct := float64(stat.StdDev) / float64(stat.Mean) * 100
+		writeWorkflowCommand(writer, "warning", "High variance",
+			fmt.Sprintf("%s: stddev is %.0f%% of mean. %s", cmd, pct, VarianceAdvice(stat)))
+	}
+
+	if stat.Drift != nil && stat.Drift.DriftingAtEnd {
+		writeWorkflowCommand(writer, "warning", "Latency drift",
+			fmt.Sprintf("%s: latency still drifting at the end, %+.0f%% over the last half of the runs.",
+				cmd, stat.Drift.EndTrend*100))
+	}
+
+	if stat.Outcomes.Failed() > 0 {
+		writeWorkflowCommand(writer, "error", "Failed runs",
+			fmt.Sprintf("%s: %s (of %d runs)", cmd, FormatOutcomes(stat.Outcomes), stat.TotalRuns))
+	}
+
+	for _, violation := range g.Thresholds.Check(stat) {
+		writeWorkflowCommand(writer, "error", "Threshold exceeded",
+			fmt.Sprintf("%s: %s", cmd, violation))
+	}
+}
+
+// writeWorkflowCommand writes a ::level title=...::message line
+func writeWorkflowCommand(writer io.Writer, level, title, message string) {
+	fmt.Fprintf(writer, "::%s title=%s::%s\n", level, escapeProperty(title), escapeData(message))
+}
+
+func escapeData(s string) string {
+	s = strings.ReplaceAll(s, "%", "%25")
+	s = strings.ReplaceAll(s, "\r", "%0D")
+	return strings.ReplaceAll(s, "\n", "%0A")
+}
+
+func escapeProperty(s string) string {
+	s = escapeData(s)
+	s = strings.ReplaceAll(s, ":", "%3A")
+	return strings.ReplaceAll(s, ",", "%2C")
+}
+
+// formatStepOutputs renders key metrics as name=value lines. Commands are
+// numbered from 1 in the order they were given.
+func formatStepOutputs(stats []*benchmark.CommandStats) string {
+	var sb strings.Builder
+
+	writeOutput := func(name, value string) {
+		if strings.ContainsAny(value, "\r\n") {
+			delimiter := "CMDPERF_EOF"
+			for strings.Contains(value, delimiter) {
+				delimiter += "_"
+			}
+			fmt.Fprintf(&sb, "%s<<%s\n%s\n%s\n", name, delimiter, value, delimiter)
+			return
+		}
+		fmt.Fprintf(&sb, "%s=%s\n", name, value)
+	}
+
+	fastestIdx := -1
+	for i, stat := range stats {
+		prefix := fmt.Sprintf("command_%d_", i+1)
+		writeOutput(prefix+"command", stat.Command.Raw)
+		writeOutput(prefix+"runs", fmt.Sprintf("%d", stat.TotalRuns))
+		writeOutput(prefix+"errors", fmt.Sprintf("%d", stat.ErrorCount))
+		writeOutput(prefix+"mean_ns", fmt.Sprintf("%d", stat.Mean.Nanoseconds()))
+		writeOutput(prefix+"median_ns", fmt.Sprintf("%d", stat.Median.Nanoseconds()))
+		writeOutput(prefix+"stddev_ns", fmt.Sprintf("%d", stat.StdDev.Nanoseconds()))
+		writeOutput(prefix+"p95_ns", fmt.Sprintf("%d", stat.P95.Nanoseconds()))
+		writeOutput(prefix+"p99_ns", fmt.Sprintf("%d", stat.P99.Nanoseconds()))
+		for _, p := range stat.Percentiles {
+			if p.P == 95 || p.P == 99 {
+				continue
+			}
+			// Output names can't have dots, so p99.9 is p99_9
+			name := "p" + strings.ReplaceAll(benchmark.FormatPercentile(p.P), ".", "_") + "_ns"
+			writeOutput(prefix+name, fmt.Sprintf("%d", p.Value.Nanoseconds()))
+		}
+		writeOutput(prefix+"throughput_per_sec", fmt.Sprintf("%f", stat.Throughput))
+
+		if stat.SuccessfulRuns > 0 && (fastestIdx < 0 || stat.Mean < stats[fastestIdx].Mean) {
+			fastestIdx = i
+		}
+	}
+
+	if fastestIdx >= 0 {
+		writeOutput("fastest_command", stats[fastestIdx].Command.Raw)
+	}
+
+	return sb.String()
+}
+
+func appendToFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
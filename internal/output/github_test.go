@@ -0,0 +1,86 @@
+package output
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+)
+
+func TestGitHubActions(t *testing.T) {
+	dir := t.TempDir()
+	summaryPath := filepath.Join(dir, "summary.md")
+	outputPath := filepath.Join(dir, "output")
+	t.Setenv("GITHUB_STEP_SUMMARY", summaryPath)
+	t.Setenv("GITHUB_OUTPUT", outputPath)
+
+	stats := createTestStats()
+	stats[0].HighVariance = true
+	stats[1].P95 = 150 * time.Millisecond
+
+	gh := GitHubActionsFromEnv()
+	gh.Thresholds = benchmark.Thresholds{MaxP95: 100 * time.Millisecond}
+
+	var buf bytes.Buffer
+	if err := gh.Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write GitHub Actions output: %v", err)
+	}
+
+	annotations := buf.String()
+	expectedAnnotations := []string{
+		"::warning title=High variance::echo hello: stddev is 50%25 of mean.",
+		"::error title=Failed runs::sleep 0.1: 5 unexpected exits (exit 1: 5) (of 100 runs)",
+		"::error title=Threshold exceeded::sleep 0.1: p95 150ms exceeds 100ms",
+	}
+	for _, annotation := range expectedAnnotations {
+		if !strings.Contains(annotations, annotation) {
+			t.Errorf("Annotations missing %q, got:\n%s", annotation, annotations)
+		}
+	}
+
+	summary, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("Failed to read step summary: %v", err)
+	}
+	if !strings.Contains(string(summary), "## Summary") {
+		t.Errorf("Step summary missing Markdown results")
+	}
+
+	outputs, err := os.ReadFile(outputPath)
+	if err != nil {
+		t.Fatalf("Failed to read step outputs: %v", err)
+	}
+	expectedOutputs := []string{
+		"command_1_command=echo hello\n",
+		"command_1_mean_ns=2000000\n",
+		"command_2_errors=5\n",
+		"fastest_command=echo hello\n",
+	}
+	for _, line := range expectedOutputs {
+		if !strings.Contains(string(outputs), line) {
+			t.Errorf("Step outputs missing %q, got:\n%s", line, outputs)
+		}
+	}
+}
+
+func TestGitHubActionsEscaping(t *testing.T) {
+	var buf bytes.Buffer
+	writeWorkflowCommand(&buf, "warning", "a:b,c", "100%\nnext")
+
+	expected := "::warning title=a%3Ab%2Cc::100%25%0Anext\n"
+	if buf.String() != expected {
+		t.Errorf("writeWorkflowCommand = %q, expected %q", buf.String(), expected)
+	}
+
+	outputs := formatStepOutputs([]*benchmark.CommandStats{{
+		Command:   createTestStats()[0].Command,
+		ExitCodes: map[int]int{},
+	}})
+	if strings.Contains(outputs, "fastest_command") {
+		t.Errorf("fastest_command set without any successful runs:\n%s", outputs)
+	}
+}
@@ -4,61 +4,342 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
+	"time"
 
 	"github.com/miklosn/cmdperf/internal/benchmark"
 )
 
 type JSONWriter struct{}
 
-type jsonStat struct {
-	Command        string  `json:"command"`
-	TotalRuns      int     `json:"total_runs"`
-	SuccessfulRuns int     `json:"successful_runs"`
-	ErrorCount     int     `json:"error_count"`
-	NonZeroExits   int     `json:"non_zero_exits"`
-	MinNs          int64   `json:"min_ns"`
-	MaxNs          int64   `json:"max_ns"`
-	MeanNs         int64   `json:"mean_ns"`
-	MedianNs       int64   `json:"median_ns"`
-	P50Ns          int64   `json:"p50_ns"`
-	P95Ns          int64   `json:"p95_ns"`
-	P99Ns          int64   `json:"p99_ns"`
-	StdDevNs       int64   `json:"stddev_ns"`
-	Throughput     float64 `json:"throughput_per_sec"`
-	TargetRate     float64 `json:"target_rate"`
+// Result holds one command's statistics. It is an element of the --json
+// output and of the library's Report, which aliases it as cmdperf.Result.
+type Result struct {
+	Command        string        `json:"command"`
+	TotalRuns      int           `json:"total_runs"`
+	SuccessfulRuns int           `json:"successful_runs"`
+	ErrorCount     int           `json:"error_count"`
+	NonZeroExits   int           `json:"non_zero_exits"`
+	Min            time.Duration `json:"min_ns"`
+	Max            time.Duration `json:"max_ns"`
+	Mean           time.Duration `json:"mean_ns"`
+	Median         time.Duration `json:"median_ns"`
+	P50            time.Duration `json:"p50_ns"`
+	P95            time.Duration `json:"p95_ns"`
+	P99            time.Duration `json:"p99_ns"`
+	StdDev         time.Duration `json:"stddev_ns"`
+	Throughput     float64       `json:"throughput_per_sec"`
+	TargetRate     float64       `json:"target_rate"`
+
+	// Percentiles holds the requested percentiles by number, such as
+	// "99.9", see Result.Percentile
+	Percentiles map[string]time.Duration `json:"percentiles_ns,omitempty"`
+
+	// With a target precision, the relative half-width of the mean's 95%
+	// confidence interval and whether it reached the target
+	Precision float64 `json:"precision,omitempty"`
+	Converged bool    `json:"converged,omitempty"`
+
+	// Outliers among the samples, nil when outliers are kept unexamined
+	Outliers *Outliers `json:"outliers,omitempty"`
+
+	// Drift of latency over the benchmark, nil with fewer than 10 runs
+	Drift *Drift `json:"drift,omitempty"`
+
+	// Modes are the peaks of the latency distribution, fastest first. More
+	// than one means the command has distinct behaviors, such as cache hits
+	// and misses, that the mean describes none of. Nil with fewer than 20
+	// runs.
+	Modes []Mode `json:"modes,omitempty"`
+
+	// ShellOverhead is the cost of starting the command's shell, measured
+	// before the benchmark; nil for commands without one.
+	// MostlyShellOverhead is set when the command's own time is within the
+	// noise of it.
+	ShellOverhead       *ShellOverhead `json:"shell_overhead,omitempty"`
+	MostlyShellOverhead bool           `json:"mostly_shell_overhead,omitempty"`
+
+	// InShell is set for commands run in a persistent shell, whose runs
+	// don't include starting a process
+	InShell bool `json:"in_shell,omitempty"`
+
+	// Phases are the mean durations of the phases of HTTP requests, nil for
+	// other commands
+	Phases []Phase `json:"phases,omitempty"`
+
+	// With an input, the bytes fed to every run and the rate they were fed
+	// at in bytes per second
+	InputBytes      int64   `json:"input_bytes,omitempty"`
+	InputThroughput float64 `json:"input_bytes_per_sec,omitempty"`
+
+	// Outcomes counts the runs by how they ended
+	Outcomes Outcomes `json:"outcomes"`
+
+	// The expected exit statuses and stdout of successful runs, when set
+	ExpectExit   []int  `json:"expect_exit,omitempty"`
+	ExpectStdout string `json:"expect_stdout,omitempty"`
+
+	// With captured output or an expected stdout, the output of the first
+	// failed runs
+	FailedRuns []FailedRun `json:"failed_runs,omitempty"`
+
+	// ExitCodes counts runs by exit code. It isn't part of the JSON output.
+	ExitCodes map[int]int `json:"-"`
+
+	// stats are the statistics the Result was made from, see ResultStats
+	stats *benchmark.CommandStats
+}
+
+// Outliers counts the samples outside Tukey's fences: more than 1.5 (mild)
+// or 3 (severe) interquartile ranges below (low) or above (high) the
+// quartiles
+type Outliers struct {
+	LowSevere  int `json:"low_severe"`
+	LowMild    int `json:"low_mild"`
+	HighMild   int `json:"high_mild"`
+	HighSevere int `json:"high_severe"`
+
+	// Samples is the number of samples classified: the runs kept for
+	// percentiles, at most 1000 unless higher percentiles need more
+	Samples int `json:"samples"`
+
+	// VarianceShare is the part of the variance due to the outliers
+	VarianceShare float64 `json:"variance_share"`
+
+	// Dropped is set when the outliers were left out of the statistics
+	Dropped bool `json:"dropped"`
+}
+
+// ShellOverhead is the time an empty command takes through a shell, such as
+// "/bin/sh -c"
+type ShellOverhead struct {
+	Shell  string        `json:"shell"`
+	Mean   time.Duration `json:"mean_ns"`
+	StdDev time.Duration `json:"stddev_ns"`
+	Runs   int           `json:"runs"`
+
+	// Subtracted is set when Mean was subtracted from every run
+	Subtracted bool `json:"subtracted"`
+}
+
+// Mode is a peak of a latency distribution, and the share of the samples
+// around it
+type Mode struct {
+	Value  time.Duration `json:"value_ns"`
+	Weight float64       `json:"weight"`
+}
+
+// Phase is the mean duration of a phase of a command's HTTP requests, over
+// the Runs that went through it: dns, connect, tls, ttfb (to the first byte
+// of the response) or total. Requests on a kept-alive connection skip dns,
+// connect and tls.
+type Phase struct {
+	Name string        `json:"phase"`
+	Mean time.Duration `json:"mean_ns"`
+	Runs int           `json:"runs"`
+}
+
+// Outcomes counts runs by how they ended. Runs that exited with an
+// unexpected status, or whose executor failed otherwise, are UnexpectedExit;
+// runs killed by a signal are Signal; runs that succeeded with an unexpected
+// stdout are ValidationFailure; runs cut short by the end of the benchmark
+// are Cancelled.
+type Outcomes struct {
+	Success           int `json:"success"`
+	UnexpectedExit    int `json:"unexpected_exit"`
+	Signal            int `json:"signal"`
+	Timeout           int `json:"timeout"`
+	SpawnFailure      int `json:"spawn_failure"`
+	ValidationFailure int `json:"validation_failure"`
+	Cancelled         int `json:"cancelled"`
+
+	// UnexpectedExitCodes counts the unexpected exits by exit status, and
+	// Signals the signaled runs by signal name, such as SIGSEGV
+	UnexpectedExitCodes map[int]int    `json:"unexpected_exit_codes,omitempty"`
+	Signals             map[string]int `json:"signals,omitempty"`
+}
+
+// FailedRun is the captured output of a failed run: the first 64 KiB of its
+// stdout and stderr
+type FailedRun struct {
+	// Run is the number of the run, counting from 1 in the order the runs
+	// completed
+	Run      int    `json:"run"`
+	ExitCode int    `json:"exit_code"`
+	Error    string `json:"error"`
+
+	// ValidationFailed is set when the run exited 0 with an unexpected
+	// stdout
+	ValidationFailed bool   `json:"validation_failed,omitempty"`
+	Stdout           string `json:"stdout"`
+	Stderr           string `json:"stderr"`
+}
+
+// Drift describes how latency changed over the course of a benchmark,
+// tested with Mann-Kendall and measured with Sen's slope
+type Drift struct {
+	// Trend is the change from the first run to the last relative to the
+	// median, Trending is set when it's significant
+	Trend    float64 `json:"trend"`
+	Trending bool    `json:"trending"`
+
+	// SteadyFrom is the first run after the warm-up: 1 without one, 0 when
+	// latency is still drifting at the end. Warmup is how much the runs
+	// before it differed from the rest, such as 0.5 for 50% slower.
+	SteadyFrom int     `json:"steady_from_run"`
+	Warmup     float64 `json:"warmup"`
+
+	// EndTrend and DriftingAtEnd are Trend and Trending for the last half of
+	// the runs
+	EndTrend      float64 `json:"end_trend"`
+	DriftingAtEnd bool    `json:"drifting_at_end"`
+}
+
+// Percentile returns the p-th percentile, such as 99.9, if it was requested
+func (r *Result) Percentile(p float64) (time.Duration, bool) {
+	value, ok := r.Percentiles[benchmark.FormatPercentile(p)]
+	return value, ok
+}
+
+// Total returns the number of outliers
+func (o *Outliers) Total() int {
+	return o.LowSevere + o.LowMild + o.HighMild + o.HighSevere
+}
+
+// Failed returns the number of runs that failed, leaving out the cancelled
+// ones
+func (o *Outcomes) Failed() int {
+	return o.UnexpectedExit + o.Signal + o.Timeout + o.SpawnFailure + o.ValidationFailure
 }
 
 func (w *JSONWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) error {
-	out := make([]jsonStat, 0, len(stats))
-	for _, s := range stats {
-		nonZero := 0
-		for code, count := range s.ExitCodes {
-			if code != 0 {
-				nonZero += count
-			}
-		}
-		out = append(out, jsonStat{
-			Command:        s.Command.Raw,
-			TotalRuns:      s.TotalRuns,
-			SuccessfulRuns: s.SuccessfulRuns,
-			ErrorCount:     s.ErrorCount,
-			NonZeroExits:   nonZero,
-			MinNs:          s.Min.Nanoseconds(),
-			MaxNs:          s.Max.Nanoseconds(),
-			MeanNs:         s.Mean.Nanoseconds(),
-			MedianNs:       s.Median.Nanoseconds(),
-			P50Ns:          s.P50.Nanoseconds(),
-			P95Ns:          s.P95.Nanoseconds(),
-			P99Ns:          s.P99.Nanoseconds(),
-			StdDevNs:       s.StdDev.Nanoseconds(),
-			Throughput:     s.Throughput,
-			TargetRate:     s.TargetRate,
-		})
-	}
 	enc := json.NewEncoder(writer)
 	enc.SetIndent("", "  ")
-	if err := enc.Encode(out); err != nil {
+	if err := enc.Encode(NewResults(stats)); err != nil {
 		return fmt.Errorf("failed to write JSON: %w", err)
 	}
 	return nil
 }
+
+// NewResults converts the runner's statistics to their Results
+func NewResults(stats []*benchmark.CommandStats) []Result {
+	results := make([]Result, 0, len(stats))
+	for _, s := range stats {
+		results = append(results, NewResult(s))
+	}
+	return results
+}
+
+// NewResult converts a command's statistics to its Result, keeping them for
+// ResultStats
+func NewResult(s *benchmark.CommandStats) Result {
+	result := Result{
+		Command:        s.Command.Raw,
+		TotalRuns:      s.TotalRuns,
+		SuccessfulRuns: s.SuccessfulRuns,
+		ErrorCount:     s.ErrorCount,
+		Min:            s.Min,
+		Max:            s.Max,
+		Mean:           s.Mean,
+		Median:         s.Median,
+		P50:            s.P50,
+		P95:            s.P95,
+		P99:            s.P99,
+		StdDev:         s.StdDev,
+		Throughput:     s.Throughput,
+		TargetRate:     s.TargetRate,
+		Precision:      s.Precision,
+		Converged:      s.Converged,
+		ExitCodes:      make(map[int]int, len(s.ExitCodes)),
+
+		MostlyShellOverhead: s.MostlyShellOverhead,
+		InShell:             s.Command.InShell,
+		InputBytes:          s.InputBytes,
+		InputThroughput:     s.InputThroughput,
+		Outcomes:            NewOutcomes(s.Outcomes),
+		ExpectExit:          s.Command.ExpectExit,
+
+		stats: s,
+	}
+	for code, count := range s.ExitCodes {
+		result.ExitCodes[code] = count
+		if code != 0 {
+			result.NonZeroExits += count
+		}
+	}
+	if len(s.Percentiles) > 0 {
+		result.Percentiles = make(map[string]time.Duration, len(s.Percentiles))
+		for _, p := range s.Percentiles {
+			result.Percentiles[benchmark.FormatPercentile(p.P)] = p.Value
+		}
+	}
+	if o := s.Outliers; o != nil {
+		result.Outliers = &Outliers{
+			LowSevere:     o.LowSevere,
+			LowMild:       o.LowMild,
+			HighMild:      o.HighMild,
+			HighSevere:    o.HighSevere,
+			Samples:       o.Samples,
+			VarianceShare: o.VarianceShare,
+			Dropped:       o.Dropped,
+		}
+	}
+	if d := s.Drift; d != nil {
+		result.Drift = &Drift{
+			Trend:         d.Trend,
+			Trending:      d.Trending,
+			SteadyFrom:    d.SteadyFrom,
+			Warmup:        d.Warmup,
+			EndTrend:      d.EndTrend,
+			DriftingAtEnd: d.DriftingAtEnd,
+		}
+	}
+	for _, mode := range s.Modes {
+		result.Modes = append(result.Modes, Mode{Value: mode.Value, Weight: mode.Weight})
+	}
+	if o := s.ShellOverhead; o != nil {
+		result.ShellOverhead = &ShellOverhead{
+			Shell:      o.Shell,
+			Mean:       o.Mean,
+			StdDev:     o.StdDev,
+			Runs:       o.Runs,
+			Subtracted: o.Subtracted,
+		}
+	}
+	for _, phase := range s.Phases {
+		result.Phases = append(result.Phases, Phase{Name: phase.Name, Mean: phase.Mean, Runs: phase.Runs})
+	}
+	if s.Command.ExpectStdout != nil {
+		result.ExpectStdout = s.Command.ExpectStdout.Spec
+	}
+	for _, run := range s.FailedRuns {
+		result.FailedRuns = append(result.FailedRuns, FailedRun(run))
+	}
+	return result
+}
+
+// NewOutcomes converts the runner's outcomes to Outcomes
+func NewOutcomes(o benchmark.Outcomes) Outcomes {
+	out := Outcomes{
+		Success:           o.Success,
+		UnexpectedExit:    o.UnexpectedExit,
+		Signal:            o.Signaled,
+		Timeout:           o.Timeout,
+		SpawnFailure:      o.SpawnFailure,
+		ValidationFailure: o.ValidationFailure,
+		Cancelled:         o.Cancelled,
+	}
+	if len(o.ExitCodes) > 0 {
+		out.UnexpectedExitCodes = maps.Clone(o.ExitCodes)
+	}
+	if len(o.Signals) > 0 {
+		out.Signals = maps.Clone(o.Signals)
+	}
+	return out
+}
+
+// ResultStats returns the statistics r was made from by NewResult, which the
+// other writers render, or nil for a Result decoded from JSON
+func ResultStats(r *Result) *benchmark.CommandStats {
+	return r.stats
+}
@@ -36,8 +36,19 @@ func (w *MarkdownWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 	}
 
 	fmt.Fprintf(bufWriter, "## Summary\n\n")
-	fmt.Fprintf(bufWriter, "| Command | Runs | Mean ± StdDev | Min | Max | Throughput | Rate | Errors | P50 | P95 | P99 |\n")
-	fmt.Fprintf(bufWriter, "|---------|------|--------------|-----|-----|------------|------|-------|-----|-----|-----|\n")
+	var percentiles []benchmark.Percentile
+	if len(stats) > 0 {
+		percentiles = Percentiles(stats[0])
+	}
+	header := "| Command | Runs | Mean ± StdDev | Min | Max | Throughput | Rate | Errors |"
+	separator := "|---------|------|--------------|-----|-----|------------|------|-------|"
+	for _, p := range percentiles {
+		label := "P" + benchmark.FormatPercentile(p.P)
+		header += " " + label + " |"
+		separator += strings.Repeat("-", len(label)+2) + "|"
+	}
+	fmt.Fprintln(bufWriter, header)
+	fmt.Fprintln(bufWriter, separator)
 
 	for _, stat := range stats {
 		meanStr := FormatDuration(stat.Mean)
@@ -53,7 +64,7 @@ func (w *MarkdownWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 			rateStr = fmt.Sprintf("%.2f/%.2f", stat.Throughput, stat.TargetRate)
 		}
 
-		fmt.Fprintf(bufWriter, "| `%s` | %d | %s ± %s | %s | %s | %s | %s | %d | %s | %s | %s |\n",
+		fmt.Fprintf(bufWriter, "| `%s` | %d | %s ± %s | %s | %s | %s | %s | %d |",
 			escapedCmd,
 			stat.TotalRuns,
 			meanStr,
@@ -62,10 +73,11 @@ func (w *MarkdownWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 			maxStr,
 			throughputStr,
 			rateStr,
-			stat.ErrorCount,
-			FormatDuration(stat.P50),
-			FormatDuration(stat.P95),
-			FormatDuration(stat.P99))
+			stat.ErrorCount)
+		for _, p := range Percentiles(stat) {
+			fmt.Fprintf(bufWriter, " %s |", FormatDuration(p.Value))
+		}
+		fmt.Fprintln(bufWriter)
 	}
 
 	fmt.Fprintf(bufWriter, "\n## Command Parameters\n\n")
@@ -80,19 +92,59 @@ func (w *MarkdownWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 		fmt.Fprintf(bufWriter, "- **Shell**: %s\n", stat.Command.Shell)
 		fmt.Fprintf(bufWriter, "- **Shell Options**: %s\n", strings.Join(stat.Command.ShellOptions, " "))
 
+		if stat.Command.InShell {
+			fmt.Fprintf(bufWriter, "- **Measured In-Shell**: runs share a persistent shell per worker, without starting a process\n")
+		}
+
+		if o := stat.ShellOverhead; o != nil {
+			subtracted := ""
+			if o.Subtracted {
+				subtracted = ", subtracted"
+			}
+			fmt.Fprintf(bufWriter, "- **Shell Overhead**: %s ± %s%s\n", FormatDuration(o.Mean), FormatDuration(o.StdDev), subtracted)
+		}
+		if stat.MostlyShellOverhead {
+			fmt.Fprintf(bufWriter, "- **Mostly Shell Startup**: the command takes about as long as an empty one\n")
+		}
+
 		if stat.ErrorCount > 0 {
 			fmt.Fprintf(bufWriter, "- **Error Count**: %d\n", stat.ErrorCount)
 		}
 
-		if len(stat.ExitCodes) > 0 {
-			fmt.Fprintf(bufWriter, "- **Exit Codes**:\n")
-			for exitCode, count := range stat.ExitCodes {
-				if exitCode == 0 {
-					fmt.Fprintf(bufWriter, "  - Success (0): %d\n", count)
-				} else {
-					fmt.Fprintf(bufWriter, "  - Error (%d): %d\n", exitCode, count)
-				}
+		if validation := FormatValidation(stat); validation != "" {
+			fmt.Fprintf(bufWriter, "- **Validation Failures**: %s\n", validation)
+		}
+
+		for _, run := range stat.FailedRuns {
+			fmt.Fprintf(bufWriter, "- **Failed Run %d**: %s\n", run.Run, run.Error)
+		}
+
+		if stat.Modes.Multimodal() {
+			fmt.Fprintf(bufWriter, "- **Modes**: %s\n", stat.Modes)
+		}
+
+		if input := FormatInput(stat); input != "" {
+			fmt.Fprintf(bufWriter, "- **Input**: %s\n", input)
+		}
+
+		if phases := FormatPhases(stat.Phases); phases != "" {
+			fmt.Fprintf(bufWriter, "- **Phases**: %s\n", phases)
+		}
+
+		if stat.Outliers != nil {
+			fmt.Fprintf(bufWriter, "- **Outliers**: %s\n", stat.Outliers)
+		}
+
+		if stat.Drift != nil {
+			fmt.Fprintf(bufWriter, "- **Drift**: %s\n", stat.Drift)
+		}
+
+		if stat.TotalRuns > 0 {
+			outcomes := fmt.Sprintf("%d succeeded", stat.Outcomes.Success)
+			if failures := FormatOutcomes(stat.Outcomes); failures != "" {
+				outcomes += ", " + failures
 			}
+			fmt.Fprintf(bufWriter, "- **Outcomes**: %s\n", outcomes)
 		}
 	}
 
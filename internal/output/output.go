@@ -19,6 +19,8 @@ func GetWriter(format string) (Writer, error) {
 		return &MarkdownWriter{}, nil
 	case "json":
 		return &JSONWriter{}, nil
+	case "cdf":
+		return &CDFWriter{}, nil
 	case "terminal":
 		return &TerminalWriter{}, nil
 	default:
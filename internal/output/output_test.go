@@ -90,6 +90,7 @@ func createTestStats() []*benchmark.CommandStats {
 		StdDev:         time.Millisecond,
 		Throughput:     500,
 		ExitCodes:      map[int]int{0: 100},
+		Outcomes:       benchmark.Outcomes{Success: 100},
 	}
 
 	stats2 := &benchmark.CommandStats{
@@ -104,6 +105,7 @@ func createTestStats() []*benchmark.CommandStats {
 		StdDev:         5 * time.Millisecond,
 		Throughput:     9,
 		ExitCodes:      map[int]int{0: 95, 1: 5},
+		Outcomes:       benchmark.Outcomes{Success: 95, UnexpectedExit: 5, ExitCodes: map[int]int{1: 5}},
 	}
 
 	return []*benchmark.CommandStats{stats1, stats2}
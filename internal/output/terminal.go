@@ -5,26 +5,38 @@ import (
 	"io"
 	"strings"
 
-	"github.com/fatih/color"
 	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
 )
 
-type TerminalWriter struct{}
+// TerminalWriter prints human-readable results in the colors of Scheme, or
+// colorscheme.Default when it is nil
+type TerminalWriter struct {
+	Scheme *colorscheme.Scheme
+}
 
 func (w *TerminalWriter) Write(writer io.Writer, stats []*benchmark.CommandStats) error {
-	headerColor := color.New(color.FgHiCyan, color.Bold).SprintFunc()
-	subheaderColor := color.New(color.FgCyan).SprintFunc()
-	commandColor := color.New(color.FgHiYellow, color.Bold).SprintFunc()
-	labelColor := color.New(color.FgHiBlue).SprintFunc()
-	valueColor := color.New(color.FgWhite).SprintFunc()
-	comparisonColor := color.New(color.FgHiMagenta, color.Bold).SprintFunc()
-	fasterColor := color.New(color.FgHiGreen, color.Bold).SprintFunc()
-	slowerColor := color.New(color.FgHiRed, color.Bold).SprintFunc()
-	errorColor := color.New(color.FgHiRed).SprintFunc()
+	scheme := w.Scheme
+	if scheme == nil {
+		scheme = colorscheme.Default()
+	}
+
+	headerColor := scheme.Header
+	subheaderColor := scheme.Subheader
+	commandColor := scheme.Command
+	labelColor := scheme.Label
+	valueColor := scheme.Value
+	comparisonColor := scheme.Comparison
+	fasterColor := scheme.Faster
+	slowerColor := scheme.Slower
+	errorColor := scheme.Error
 
 	fmt.Fprintln(writer, "\n"+headerColor("✨ cmdperf - Command Performance Benchmarking ✨"))
 	fmt.Fprintln(writer, strings.Repeat("━", 50))
 	fmt.Fprintln(writer, subheaderColor(fmt.Sprintf("Timer overhead: ~%s", FormatDuration(benchmark.TimerOverhead()))))
+	for _, line := range ShellOverheads(stats) {
+		fmt.Fprintln(writer, subheaderColor(line))
+	}
 
 	// Adjust header based on whether rate limiting is active
 	throughputHeader := "Throughput"
@@ -92,35 +104,85 @@ func (w *TerminalWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 		fmt.Fprint(writer, valueColor(line))
 
 		if stat.SuccessfulRuns > 0 {
-			fmt.Fprintf(writer, "  %s %s  %s %s  %s %s\n",
-				labelColor("P50:"), valueColor(FormatDuration(stat.P50)),
-				labelColor("P95:"), valueColor(FormatDuration(stat.P95)),
-				labelColor("P99:"), valueColor(FormatDuration(stat.P99)))
+			percentiles := Percentiles(stat)
+			parts := make([]string, len(percentiles))
+			for i, p := range percentiles {
+				parts[i] = fmt.Sprintf("%s %s",
+					labelColor("P"+benchmark.FormatPercentile(p.P)+":"), valueColor(FormatDuration(p.Value)))
+			}
+			fmt.Fprintf(writer, "  %s\n", strings.Join(parts, "  "))
+		}
+
+		if phases := FormatPhases(stat.Phases); phases != "" {
+			fmt.Fprintf(writer, "  %s %s\n", labelColor("Phases:"), valueColor(phases))
+		}
+
+		if input := FormatInput(stat); input != "" {
+			fmt.Fprintf(writer, "  %s %s\n", labelColor("Input:"), valueColor(input))
+		}
+
+		if note := InShellNote(stat); note != "" {
+			fmt.Fprintf(writer, "  %s\n", labelColor(note))
+		}
+
+		if stat.Precision > 0 {
+			precision := fmt.Sprintf("Mean within ±%.2f%% (95%% confidence)", stat.Precision*100)
+			if stat.TargetPrecision > 0 && !stat.Converged {
+				fmt.Fprintf(writer, "  %s\n", slowerColor(fmt.Sprintf("⚠ %s, short of the ±%.2f%% target.",
+					precision, stat.TargetPrecision*100)))
+			} else {
+				fmt.Fprintf(writer, "  %s\n", labelColor(precision))
+			}
+		}
+
+		if stat.Modes.Multimodal() {
+			modes := make([]string, len(stat.Modes))
+			for i, mode := range stat.Modes {
+				modes[i] = fmt.Sprintf("%s (%.0f%%)", FormatDuration(mode.Value), mode.Weight*100)
+			}
+			fmt.Fprintf(writer, "  %s\n", slowerColor(fmt.Sprintf(
+				"⚠ Multimodal: %s. The mean describes none of them.", strings.Join(modes, ", "))))
+		}
+
+		if warning := ShellOverheadWarning(stat); warning != "" {
+			fmt.Fprintf(writer, "  %s\n", slowerColor(warning))
+		}
+
+		if stat.Outliers != nil && stat.Outliers.Total() > 0 {
+			fmt.Fprintf(writer, "  %s %s\n", labelColor("Outliers:"), valueColor(stat.Outliers.String()))
+		}
+
+		if d := stat.Drift; d != nil {
+			if d.SteadyFrom > 1 {
+				fmt.Fprintf(writer, "  %s %s\n", labelColor("Steady state:"),
+					valueColor(fmt.Sprintf("from run %d (the runs before it %+.0f%%)", d.SteadyFrom, d.Warmup*100)))
+			}
+			if d.DriftingAtEnd {
+				fmt.Fprintf(writer, "  %s\n", slowerColor(fmt.Sprintf(
+					"⚠ Latency still drifting at the end (%+.0f%% over the last half of the runs). Throttling, filling caches or a leak?",
+					d.EndTrend*100)))
+			}
 		}
 
 		if stat.HighVariance && stat.Mean > 0 {
 			pct := float64(stat.StdDev) / float64(stat.Mean) * 100
 			fmt.Fprintf(writer, "  %s\n",
-				slowerColor(fmt.Sprintf("⚠ High variance (stddev %.0f%% of mean). Try more runs.", pct)))
+				slowerColor(fmt.Sprintf("⚠ High variance (stddev %.0f%% of mean). %s", pct, VarianceAdvice(stat))))
 		}
 
-		hasNonZeroExitCodes := false
-		for exitCode, count := range stat.ExitCodes {
-			if exitCode != 0 && count > 0 {
-				hasNonZeroExitCodes = true
-				break
-			}
+		if failures := FormatOutcomes(stat.Outcomes); failures != "" {
+			fmt.Fprintf(writer, "\n  %s %s\n", labelColor("Failed runs:"), slowerColor(failures))
 		}
 
-		if hasNonZeroExitCodes {
-			fmt.Fprintf(writer, "\n  %s\n", labelColor("Exit Codes:"))
-			for exitCode, count := range stat.ExitCodes {
-				if exitCode != 0 {
-					fmt.Fprintf(writer, "    %s: %d\n",
-						slowerColor(fmt.Sprintf("Exit %d", exitCode)),
-						count)
-				}
-			}
+		if validation := FormatValidation(stat); validation != "" {
+			fmt.Fprintf(writer, "\n  %s\n", errorColor("⚠ "+validation))
+		}
+
+		if len(stat.FailedRuns) > 0 {
+			run := stat.FailedRuns[0]
+			fmt.Fprintf(writer, "\n  %s %s\n", labelColor(fmt.Sprintf("First failed run (#%d):", run.Run)), errorColor(run.Error))
+			writeExcerpt(writer, "stdout:", run.Stdout, labelColor)
+			writeExcerpt(writer, "stderr:", run.Stderr, labelColor)
 		}
 	}
 
@@ -152,3 +214,13 @@ func (w *TerminalWriter) Write(writer io.Writer, stats []*benchmark.CommandStats
 
 	return nil
 }
+
+// writeExcerpt writes the beginning of a failed run's captured output, labelled
+func writeExcerpt(writer io.Writer, label, output string, labelColor colorscheme.ColorFunc) {
+	for i, line := range OutputExcerpt(output, 5) {
+		if i > 0 {
+			label = ""
+		}
+		fmt.Fprintf(writer, "    %s %s\n", labelColor(fmt.Sprintf("%-7s", label)), line)
+	}
+}
@@ -4,6 +4,10 @@ import (
 	"bytes"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
 )
 
 func TestTerminalWriter(t *testing.T) {
@@ -37,3 +41,115 @@ func TestTerminalWriter(t *testing.T) {
 		t.Errorf("Terminal output missing comparison data")
 	}
 }
+
+func TestTerminalWriterUsesScheme(t *testing.T) {
+	tag := func(name string) colorscheme.ColorFunc {
+		return func(s string) string { return "<" + name + ">" + s }
+	}
+	scheme := &colorscheme.Scheme{
+		Header:     tag("header"),
+		Subheader:  tag("subheader"),
+		Command:    tag("command"),
+		Label:      tag("label"),
+		Value:      tag("value"),
+		Error:      tag("error"),
+		Comparison: tag("comparison"),
+		Faster:     tag("faster"),
+		Slower:     tag("slower"),
+	}
+
+	var buf bytes.Buffer
+	writer := &TerminalWriter{Scheme: scheme}
+	if err := writer.Write(&buf, createTestStats()); err != nil {
+		t.Fatalf("Failed to write Terminal output: %v", err)
+	}
+
+	output := buf.String()
+	for _, want := range []string{"<header>✨ cmdperf", "<label>Command:", "<command>echo hello", "<comparison>⚡ Comparison:", "<faster>↓"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Terminal output missing %q", want)
+		}
+	}
+}
+
+func TestTerminalWriterOutliers(t *testing.T) {
+	stats := createTestStats()
+	stats[0].HighVariance = true
+	stats[0].Outliers = &benchmark.Outliers{HighSevere: 2, HighMild: 1, Samples: 100, VarianceShare: 0.8}
+
+	var buf bytes.Buffer
+	if err := (&TerminalWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write Terminal output: %v", err)
+	}
+
+	output := buf.String()
+	for _, content := range []string{
+		"Outliers: 3 of 100 samples (1 high mild, 2 high severe) causing 80% of the variance",
+		"80% of it is from 3 outliers, likely interference",
+	} {
+		if !strings.Contains(output, content) {
+			t.Errorf("Terminal output missing %q:\n%s", content, output)
+		}
+	}
+}
+
+func TestTerminalWriterDrift(t *testing.T) {
+	stats := createTestStats()
+	stats[0].Drift = &benchmark.Drift{SteadyFrom: 31, Warmup: 0.3}
+	stats[1].Drift = &benchmark.Drift{Trend: 0.4, Trending: true, EndTrend: 0.2, DriftingAtEnd: true}
+
+	var buf bytes.Buffer
+	if err := (&TerminalWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write Terminal output: %v", err)
+	}
+
+	output := buf.String()
+	for _, content := range []string{
+		"Steady state: from run 31 (the runs before it +30%)",
+		"Latency still drifting at the end (+20% over the last half of the runs)",
+	} {
+		if !strings.Contains(output, content) {
+			t.Errorf("Terminal output missing %q:\n%s", content, output)
+		}
+	}
+}
+
+func TestTerminalWriterModes(t *testing.T) {
+	stats := createTestStats()
+	stats[0].Modes = benchmark.Modes{{Value: time.Millisecond, Weight: 0.7}, {Value: 10 * time.Millisecond, Weight: 0.3}}
+	stats[1].Modes = benchmark.Modes{{Value: 100 * time.Millisecond, Weight: 1}}
+
+	var buf bytes.Buffer
+	if err := (&TerminalWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write Terminal output: %v", err)
+	}
+
+	output := buf.String()
+	if want := "Multimodal: 1.00 ms (70%), 10.00 ms (30%)"; !strings.Contains(output, want) {
+		t.Errorf("Terminal output missing %q:\n%s", want, output)
+	}
+	if strings.Count(output, "Multimodal") != 1 {
+		t.Errorf("Want the unimodal command without a warning:\n%s", output)
+	}
+}
+
+func TestTerminalWriterShellOverhead(t *testing.T) {
+	stats := createTestStats()
+	overhead := &benchmark.ShellOverhead{Shell: "/bin/sh -c", Mean: time.Millisecond, StdDev: 100 * time.Microsecond, Runs: 30, Subtracted: true}
+	stats[0].ShellOverhead = overhead
+	stats[0].MostlyShellOverhead = true
+	stats[1].ShellOverhead = overhead
+
+	var buf bytes.Buffer
+	if err := (&TerminalWriter{}).Write(&buf, stats); err != nil {
+		t.Fatalf("Failed to write Terminal output: %v", err)
+	}
+
+	output := buf.String()
+	if want := "Shell overhead (/bin/sh -c): ~1.00 ms ± 100.00 µs, subtracted from every run"; strings.Count(output, want) != 1 {
+		t.Errorf("Terminal output wants %q once:\n%s", want, output)
+	}
+	if strings.Count(output, "Mostly shell startup") != 1 {
+		t.Errorf("Want a warning for the first command only:\n%s", output)
+	}
+}
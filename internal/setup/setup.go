@@ -0,0 +1,193 @@
+// Package setup turns the settings that the CLI's flags and the library's
+// Options share into configured commands and a runner, so that both check
+// and apply them the same way.
+package setup
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// Config is a benchmark's configuration. Its zero values are unset; the CLI
+// and the library apply their defaults before.
+type Config struct {
+	// Runner configures the runner. Its Parallelism and Timeout also apply
+	// to every command.
+	Runner benchmark.Options
+
+	// Shell and ShellOptions run command lines, unless NoShell executes
+	// them directly or PersistentShell runs them in a long-lived shell
+	Shell           string
+	ShellOptions    []string
+	NoShell         bool
+	PersistentShell bool
+
+	// KillSignal, unless zero or SIGKILL, is sent to runs at their timeout,
+	// and SIGKILL KillGrace later
+	KillSignal syscall.Signal
+	KillGrace  time.Duration
+
+	// Input is a file fed to the stdin of every run. InputFrom is a command
+	// run once through the shell to generate it instead.
+	Input             string
+	InputFrom         string
+	IncludeInputWrite bool
+
+	// ExpectExit holds the exit statuses of successful runs, once for all
+	// commands or once per command
+	ExpectExit [][]int
+
+	CaptureOutput bool
+	ExpectStdout  string
+}
+
+// Command creates the command that runs line through the shell, directly
+// with NoShell, or in a persistent shell with PersistentShell
+func (c *Config) Command(line string) (*command.Command, error) {
+	switch {
+	case line == "":
+		return nil, errors.New("empty command")
+	case c.NoShell && c.PersistentShell:
+		return nil, errors.New("commands can't be run both without a shell and in a persistent one")
+	case c.NoShell:
+		return command.NewDirect(line)
+	case c.PersistentShell:
+		return command.NewPersistent(line, c.Shell, c.ShellOptions)
+	}
+	return command.NewShell(line, c.Shell, c.ShellOptions), nil
+}
+
+// NewRunner applies c to commands and creates their runner. Call cleanup
+// once the benchmark is done, to remove the output of InputFrom.
+func (c *Config) NewRunner(commands []*command.Command) (runner *benchmark.Runner, cleanup func(), err error) {
+	cleanup = func() {}
+	if err := c.check(commands); err != nil {
+		return nil, cleanup, err
+	}
+
+	var expect *command.Expect
+	if c.ExpectStdout != "" {
+		if expect, err = command.ParseExpect(c.ExpectStdout); err != nil {
+			return nil, cleanup, fmt.Errorf("expected stdout: %w", err)
+		}
+	}
+	if c.Runner.FailuresDir != "" {
+		if err := os.MkdirAll(c.Runner.FailuresDir, 0o700); err != nil {
+			return nil, cleanup, fmt.Errorf("failures directory: %w", err)
+		}
+	}
+	stdin, cleanup, err := c.stdin()
+	if err != nil {
+		return nil, cleanup, err
+	}
+
+	for i, cmd := range commands {
+		cmd.Timeout = c.Runner.Timeout
+		cmd.Parallelism = c.Runner.Parallelism
+		cmd.Stdin = stdin
+		cmd.SetCaptureOutput(c.CaptureOutput, c.Runner.FailuresDir != "")
+		cmd.ExpectStdout = expect
+		if len(c.ExpectExit) > 0 {
+			cmd.ExpectExit = c.ExpectExit[min(i, len(c.ExpectExit)-1)]
+		}
+		if c.KillSignal != 0 && c.KillSignal != syscall.SIGKILL {
+			cmd.KillSignal = c.KillSignal
+			cmd.KillGrace = c.KillGrace
+		}
+	}
+
+	options := c.Runner
+	if options.TargetPrecision <= 0 {
+		options.MinRuns, options.MaxRuns, options.MaxTime = 0, 0, 0
+	}
+	if runner, err = benchmark.NewRunner(commands, options); err != nil {
+		cleanup()
+		return nil, func() {}, err
+	}
+	return runner, cleanup, nil
+}
+
+// check fails when a setting doesn't apply to one of commands: input,
+// captured output and expected stdout need a process for every run, and exit
+// statuses and kill signals need a command line
+func (c *Config) check(commands []*command.Command) error {
+	if c.Input != "" && c.InputFrom != "" {
+		return errors.New("input can't be both a file and the output of a command")
+	}
+	if c.IncludeInputWrite && c.Input == "" && c.InputFrom == "" {
+		return errors.New("including the input write needs an input")
+	}
+	if len(c.ExpectExit) > 1 && len(c.ExpectExit) != len(commands) {
+		return fmt.Errorf("exit statuses given %d times for %d commands: give them once for all of them, or once per command",
+			len(c.ExpectExit), len(commands))
+	}
+
+	processOnly := c.Input != "" || c.InputFrom != "" || c.CaptureOutput || c.ExpectStdout != ""
+	killSignal := c.KillSignal != 0 && c.KillSignal != syscall.SIGKILL
+	for _, cmd := range commands {
+		if processOnly && cmd.Executor != nil {
+			return fmt.Errorf("%q: input, captured output and expected stdout need a process for every run, not a persistent shell or an executor", cmd.Raw)
+		}
+		if cmd.Executor != nil && !cmd.InShell {
+			if len(c.ExpectExit) > 0 {
+				return fmt.Errorf("%q: expected exit statuses need a command line", cmd.Raw)
+			}
+			if killSignal {
+				return fmt.Errorf("%q: a kill signal needs a command line", cmd.Raw)
+			}
+		}
+	}
+	return nil
+}
+
+// stdin returns the input of every run, or nil without one. The output of
+// InputFrom is kept in a temporary file until remove is called.
+func (c *Config) stdin() (stdin *command.Stdin, remove func(), err error) {
+	remove = func() {}
+	path := c.Input
+	if path == "" && c.InputFrom == "" {
+		return nil, remove, nil
+	}
+
+	if c.InputFrom != "" {
+		if path, err = c.generateInput(); err != nil {
+			return nil, remove, err
+		}
+		remove = func() { os.Remove(path) }
+	}
+	if stdin, err = command.NewStdin(path, c.IncludeInputWrite); err != nil {
+		remove()
+		return nil, func() {}, err
+	}
+	return stdin, remove, nil
+}
+
+// generateInput runs InputFrom once through the shell and saves its output
+// to a temporary file, returning its path
+func (c *Config) generateInput() (string, error) {
+	f, err := os.CreateTemp("", "cmdperf-input-*")
+	if err != nil {
+		return "", err
+	}
+
+	args := append(append([]string{}, c.ShellOptions...), c.InputFrom)
+	cmd := exec.Command(c.Shell, args...)
+	cmd.Stdout = f
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", fmt.Errorf("generating the input with %q: %w", c.InputFrom, err)
+	}
+	return f.Name(), nil
+}
@@ -0,0 +1,87 @@
+package setup
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func newConfig() *Config {
+	return &Config{
+		Runner:       benchmark.Options{Iterations: 1, Parallelism: 1},
+		Shell:        "/bin/sh",
+		ShellOptions: []string{"-c"},
+	}
+}
+
+func TestNewRunnerChecks(t *testing.T) {
+	tcp, err := command.NewTCP("127.0.0.1:1", nil)
+	if err != nil {
+		t.Fatalf("Failed to create TCP command: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		configure func(c *Config)
+		commands  []*command.Command
+		err       string
+	}{
+		{"both inputs", func(c *Config) { c.Input, c.InputFrom = "in", "echo" }, nil, "both a file"},
+		{"input write without input", func(c *Config) { c.IncludeInputWrite = true }, nil, "needs an input"},
+		{"exit statuses per command", func(c *Config) { c.ExpectExit = [][]int{{0}, {1}} },
+			[]*command.Command{command.NewShell("true", "/bin/sh", nil)}, "given 2 times for 1 commands"},
+		{"capture without a process", func(c *Config) { c.CaptureOutput = true }, []*command.Command{tcp}, "need a process"},
+		{"exit statuses without a command line", func(c *Config) { c.ExpectExit = [][]int{{0}} }, []*command.Command{tcp}, "exit statuses need"},
+		{"kill signal without a command line", func(c *Config) { c.KillSignal = syscall.SIGTERM }, []*command.Command{tcp}, "kill signal needs"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := newConfig()
+			test.configure(config)
+			_, cleanup, err := config.NewRunner(test.commands)
+			cleanup()
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("NewRunner() error = %v, want one containing %q", err, test.err)
+			}
+		})
+	}
+}
+
+func TestNewRunnerInputFrom(t *testing.T) {
+	config := newConfig()
+	config.InputFrom = "echo hello"
+	config.ExpectExit = [][]int{{0}, {1}}
+	config.KillSignal = syscall.SIGTERM
+	commands := []*command.Command{
+		command.NewShell("grep -q hello", "/bin/sh", []string{"-c"}),
+		command.NewShell("grep -q bye", "/bin/sh", []string{"-c"}),
+	}
+
+	runner, cleanup, err := config.NewRunner(commands)
+	if err != nil {
+		t.Fatalf("NewRunner failed: %v", err)
+	}
+	path := commands[0].Stdin.Path
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("Generated input missing: %v", err)
+	}
+	if commands[1].ExpectExit[0] != 1 || commands[1].KillSignal != syscall.SIGTERM {
+		t.Errorf("Second command not configured: ExpectExit %v, KillSignal %v", commands[1].ExpectExit, commands[1].KillSignal)
+	}
+
+	runner.Run(context.Background())
+	cleanup()
+	for _, stats := range runner.Results {
+		if stats.ErrorCount != 0 {
+			t.Errorf("'%s' failed %d times", stats.Command.Raw, stats.ErrorCount)
+		}
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("Generated input not removed: %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"time"
+)
+
+// Eighth blocks from lowest to tallest, used for both chart types
+var blockBars = []rune("▁▂▃▄▅▆▇█")
+
+// histogram draws the distribution of samples as a row of width vertical
+// bars spanning the sample range. Empty bins are blank, so gaps between modes
+// (a cache hit vs miss, say) stay visible.
+func histogram(samples []time.Duration, width int) string {
+	if len(samples) == 0 || width <= 0 {
+		return ""
+	}
+
+	lo, hi := samples[0], samples[0]
+	for _, s := range samples {
+		if s < lo {
+			lo = s
+		}
+		if s > hi {
+			hi = s
+		}
+	}
+
+	counts := make([]int, width)
+	span := float64(hi - lo)
+	for _, s := range samples {
+		bin := width / 2
+		if span > 0 {
+			bin = int(float64(s-lo) / span * float64(width-1))
+		}
+		counts[bin]++
+	}
+
+	values := make([]float64, width)
+	for i, c := range counts {
+		values[i] = float64(c)
+	}
+	return sparkline(values)
+}
+
+// sparkline draws one bar per value, scaled so the largest gets a full block.
+// Zero is drawn as a space, and any non-zero value gets at least the lowest
+// block.
+func sparkline(values []float64) string {
+	peak := 0.0
+	for _, v := range values {
+		if v > peak {
+			peak = v
+		}
+	}
+
+	var sb strings.Builder
+	for _, v := range values {
+		if v <= 0 || peak <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		idx := int(v / peak * float64(len(blockBars)-1))
+		sb.WriteRune(blockBars[idx])
+	}
+	return sb.String()
+}
+
+// durationsToFloats converts durations to plain numbers for sparkline
+func durationsToFloats(durations []time.Duration) []float64 {
+	values := make([]float64, len(durations))
+	for i, d := range durations {
+		values[i] = float64(d)
+	}
+	return values
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values []float64
+		want   string
+	}{
+		{nil, ""},
+		{[]float64{0, 0}, "  "},
+		{[]float64{1, 2, 4, 8}, "▁▂▄█"},
+		{[]float64{0, 0.01, 1}, " ▁█"},
+	}
+	for _, test := range tests {
+		if got := sparkline(test.values); got != test.want {
+			t.Errorf("sparkline(%v) = %q, want %q", test.values, got, test.want)
+		}
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	ms := time.Millisecond
+	tests := []struct {
+		samples []time.Duration
+		width   int
+		want    string
+	}{
+		{nil, 5, ""},
+		{[]time.Duration{ms}, 0, ""},
+		// All samples alike land in the middle
+		{[]time.Duration{ms, ms}, 5, "  █  "},
+		// Two modes with a gap between them
+		{[]time.Duration{ms, ms, ms, ms, 5 * ms, 5 * ms}, 5, "█   ▄"},
+	}
+	for _, test := range tests {
+		got := histogram(test.samples, test.width)
+		if got != test.want {
+			t.Errorf("histogram(%v, %d) = %q, want %q", test.samples, test.width, got, test.want)
+		}
+		if test.want != "" && len([]rune(got)) != test.width {
+			t.Errorf("histogram(%v, %d) is %d wide", test.samples, test.width, len([]rune(got)))
+		}
+	}
+}
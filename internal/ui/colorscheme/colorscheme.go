@@ -3,6 +3,7 @@ package colorscheme
 import (
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/muesli/termenv"
@@ -30,19 +31,72 @@ type Scheme struct {
 	Slower     ColorFunc
 }
 
-// Get the terminal's color profile and determine if it supports RGB colors
+// Color modes accepted by SetColorMode
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+)
+
+// Get the terminal's color profile and determine if it supports RGB colors.
+// Every scheme, and so every writer using one, is colored according to it.
 var (
 	output      = termenv.NewOutput(os.Stdout)
-	profile     = output.ColorProfile()
+	profile     = detectProfile(ColorAuto)
 	supportsRGB = profile == termenv.TrueColor
 )
 
+// SetColorMode sets when schemes use colors. Auto colors terminal output
+// unless NO_COLOR is set, and any output when CLICOLOR_FORCE is set to
+// something other than 0; always and never ignore both. It affects schemes
+// created after the call, so call it before creating any.
+func SetColorMode(mode string) error {
+	switch mode {
+	case ColorAuto, ColorAlways, ColorNever:
+	default:
+		return fmt.Errorf("unknown color mode: %s (use auto, always or never)", mode)
+	}
+
+	profile = detectProfile(mode)
+	supportsRGB = profile == termenv.TrueColor
+	return nil
+}
+
+// detectProfile returns the color profile for mode
+func detectProfile(mode string) termenv.Profile {
+	switch mode {
+	case ColorNever:
+		return termenv.Ascii
+	case ColorAlways:
+		return forcedProfile()
+	}
+
+	if output.EnvNoColor() {
+		return termenv.Ascii
+	}
+	if forced := os.Getenv("CLICOLOR_FORCE"); forced != "" && forced != "0" {
+		return forcedProfile()
+	}
+	return output.ColorProfile()
+}
+
+// forcedProfile returns the profile TERM and COLORTERM describe, regardless
+// of whether stdout is a terminal. Without either, as is common in CI, it
+// assumes full color since whoever forced colors expects to see them.
+func forcedProfile() termenv.Profile {
+	forced := termenv.NewOutput(os.Stdout, termenv.WithTTY(true)).ColorProfile()
+	if forced == termenv.Ascii {
+		return termenv.TrueColor
+	}
+	return forced
+}
+
 // Helper function to create a styled text function
 func colorize(hex string, bold bool) ColorFunc {
 	// For ASCII terminals, fall back to monochrome
 	if profile == termenv.Ascii {
 		if bold {
-			return func(s string) string { return termenv.String(s).Bold().String() }
+			return func(s string) string { return profile.String(s).Bold().String() }
 		}
 		return func(s string) string { return s }
 	}
@@ -111,39 +165,60 @@ func GetAdaptiveScheme() *Scheme {
 	}
 }
 
-// GetScheme returns a color scheme by name
+// GetScheme returns a color scheme by name. Names that look like a path, or
+// end in .toml, are loaded with LoadFile; other names not built in are looked
+// up in UserDir.
 func GetScheme(name string) (*Scheme, error) {
-	name = strings.ToLower(name)
+	if IsFile(name) {
+		scheme, err := LoadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		if !supportsRGB {
+			return Monochrome(), nil
+		}
+		return scheme, nil
+	}
+
+	key := strings.ToLower(name)
 
 	// If terminal doesn't support RGB colors, fall back to monochrome
 	// Only fall back if not explicitly requesting monochrome
-	if !supportsRGB && name != "monochrome" {
+	if !supportsRGB && key != "monochrome" {
 		return Monochrome(), nil
 	}
 
+	if scheme := builtinScheme(key); scheme != nil {
+		return scheme, nil
+	}
+	return loadUserScheme(name)
+}
+
+// builtinScheme returns the compiled-in scheme called name, or nil
+func builtinScheme(name string) *Scheme {
 	switch name {
 	case "default":
-		return Default(), nil
+		return Default()
 	case "auto":
-		return GetAdaptiveScheme(), nil
+		return GetAdaptiveScheme()
 	case "catppuccin":
-		return Catppuccin(), nil
+		return Catppuccin()
 	case "tokyonight":
-		return TokyoNight(), nil
+		return TokyoNight()
 	case "nord":
-		return Nord(), nil
+		return Nord()
 	case "monokai":
-		return Monokai(), nil
+		return Monokai()
 	case "solarized":
-		return Solarized(), nil
+		return Solarized()
 	case "solarized-light":
-		return SolarizedLight(), nil
+		return SolarizedLight()
 	case "gruvbox":
-		return Gruvbox(), nil
+		return Gruvbox()
 	case "monochrome":
-		return Monochrome(), nil
+		return Monochrome()
 	default:
-		return nil, fmt.Errorf("unknown color scheme: %s", name)
+		return nil
 	}
 }
 
@@ -168,13 +243,37 @@ func Default() *Scheme {
 	return Catppuccin()
 }
 
-// FormatSchemeList returns a formatted string listing all available color schemes
+// FormatSchemeList returns a formatted string listing all available color
+// schemes, including the user's
 func FormatSchemeList() string {
 	var sb strings.Builder
 	sb.WriteString("Available color schemes:\n")
 
 	for _, name := range ListSchemes() {
-		scheme, _ := GetScheme(name)
+		sb.WriteString(fmt.Sprintf("  - %s: %s\n", name, builtinScheme(name).Description))
+	}
+
+	dir, err := UserDir()
+	if err != nil {
+		return sb.String()
+	}
+	names, err := ListUserSchemes()
+	if err != nil {
+		sb.WriteString(fmt.Sprintf("\nUser color schemes could not be listed: %v\n", err))
+		return sb.String()
+	}
+	if len(names) == 0 {
+		sb.WriteString(fmt.Sprintf("\nAdd your own as %s/<name>%s, or pass a file path.\n", dir, FileExtension))
+		return sb.String()
+	}
+
+	sb.WriteString(fmt.Sprintf("\nUser color schemes (%s):\n", dir))
+	for _, name := range names {
+		scheme, err := LoadFile(filepath.Join(dir, name+FileExtension))
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("  - %s: invalid: %v\n", name, err))
+			continue
+		}
 		sb.WriteString(fmt.Sprintf("  - %s: %s\n", name, scheme.Description))
 	}
 
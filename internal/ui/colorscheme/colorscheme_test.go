@@ -0,0 +1,60 @@
+package colorscheme
+
+import (
+	"testing"
+
+	"github.com/muesli/termenv"
+)
+
+func TestDetectProfile(t *testing.T) {
+	tests := []struct {
+		name string
+		mode string
+		env  map[string]string
+		want termenv.Profile
+	}{
+		{"auto without a terminal", ColorAuto, nil, termenv.Ascii},
+		{"never", ColorNever, map[string]string{"CLICOLOR_FORCE": "1", "COLORTERM": "truecolor"}, termenv.Ascii},
+		{"always", ColorAlways, map[string]string{"NO_COLOR": "1", "COLORTERM": "truecolor"}, termenv.TrueColor},
+		{"always from TERM", ColorAlways, map[string]string{"TERM": "xterm-256color"}, termenv.ANSI256},
+		{"always without TERM", ColorAlways, map[string]string{"TERM": ""}, termenv.TrueColor},
+		{"CLICOLOR_FORCE", ColorAuto, map[string]string{"CLICOLOR_FORCE": "1", "COLORTERM": "truecolor"}, termenv.TrueColor},
+		{"CLICOLOR_FORCE=0", ColorAuto, map[string]string{"CLICOLOR_FORCE": "0", "COLORTERM": "truecolor"}, termenv.Ascii},
+		{"NO_COLOR wins", ColorAuto, map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, termenv.Ascii},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"NO_COLOR", "CLICOLOR", "CLICOLOR_FORCE", "COLORTERM"} {
+				t.Setenv(key, "")
+			}
+			t.Setenv("TERM", "xterm")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			if got := detectProfile(tt.mode); got != tt.want {
+				t.Errorf("detectProfile(%q) = %v, want %v", tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetColorMode(t *testing.T) {
+	defer func() { _ = SetColorMode(ColorAuto) }()
+
+	if err := SetColorMode("sometimes"); err == nil {
+		t.Error("SetColorMode(\"sometimes\") expected error, got nil")
+	}
+
+	if err := SetColorMode(ColorNever); err != nil {
+		t.Fatalf("SetColorMode failed: %v", err)
+	}
+	scheme, err := GetScheme("nord")
+	if err != nil {
+		t.Fatalf("GetScheme failed: %v", err)
+	}
+	if got := scheme.Header("text"); got != "text" {
+		t.Errorf("Header with colors off = %q, want plain text", got)
+	}
+}
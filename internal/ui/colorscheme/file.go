@@ -0,0 +1,183 @@
+package colorscheme
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FileExtension is the extension of color scheme files
+const FileExtension = ".toml"
+
+// schemeFile is the TOML layout of a color scheme file:
+//
+//	name = "Dracula"
+//	description = "Dark theme with vivid colors"
+//
+//	[header]
+//	color = "#bd93f9"
+//	bold = true
+//
+// with one table for every element: header, subheader, command, label, value,
+// progress, completed, error, comparison, faster and slower.
+type schemeFile struct {
+	Name        string `toml:"name"`
+	Description string `toml:"description"`
+
+	Header     *elementStyle `toml:"header"`
+	Subheader  *elementStyle `toml:"subheader"`
+	Command    *elementStyle `toml:"command"`
+	Label      *elementStyle `toml:"label"`
+	Value      *elementStyle `toml:"value"`
+	Progress   *elementStyle `toml:"progress"`
+	Completed  *elementStyle `toml:"completed"`
+	Error      *elementStyle `toml:"error"`
+	Comparison *elementStyle `toml:"comparison"`
+	Faster     *elementStyle `toml:"faster"`
+	Slower     *elementStyle `toml:"slower"`
+}
+
+type elementStyle struct {
+	Color string `toml:"color"`
+	Bold  bool   `toml:"bold"`
+}
+
+var hexColor = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// IsFile reports whether name refers to a scheme file rather than a scheme
+// name
+func IsFile(name string) bool {
+	return strings.HasSuffix(name, FileExtension) || strings.ContainsRune(name, os.PathSeparator) || strings.Contains(name, "/")
+}
+
+// UserDir returns the directory user color schemes are looked up in,
+// cmdperf/schemes under the user's config directory
+func UserDir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cmdperf", "schemes"), nil
+}
+
+// ListUserSchemes returns the names of the schemes in UserDir, without the
+// file extension. A missing directory is not an error.
+func ListUserSchemes() ([]string, error) {
+	dir, err := UserDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != FileExtension {
+			continue
+		}
+		names = append(names, strings.TrimSuffix(entry.Name(), FileExtension))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// loadUserScheme loads the scheme called name from UserDir
+func loadUserScheme(name string) (*Scheme, error) {
+	dir, err := UserDir()
+	if err != nil {
+		return nil, fmt.Errorf("unknown color scheme: %s", name)
+	}
+
+	path := filepath.Join(dir, name+FileExtension)
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("unknown color scheme: %s (no built-in scheme or %s)", name, path)
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads a color scheme from a TOML file. Every element needs a hex
+// color such as "#89b4fa" or "#fff"; bold is optional.
+func LoadFile(path string) (*Scheme, error) {
+	var file schemeFile
+	meta, err := toml.DecodeFile(path, &file)
+	if err != nil {
+		var parseErr toml.ParseError
+		if errors.As(err, &parseErr) {
+			return nil, fmt.Errorf("color scheme %s: line %d: %s", path, parseErr.Position.Line, parseErr.Message)
+		}
+		return nil, fmt.Errorf("color scheme %s: %v", path, err)
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		keys := make([]string, len(undecoded))
+		for i, key := range undecoded {
+			keys[i] = key.String()
+		}
+		return nil, fmt.Errorf("color scheme %s: unknown keys: %s", path, strings.Join(keys, ", "))
+	}
+
+	if file.Name == "" {
+		file.Name = strings.TrimSuffix(filepath.Base(path), FileExtension)
+	}
+	if file.Description == "" {
+		file.Description = "Custom color scheme from " + path
+	}
+
+	scheme := &Scheme{Name: file.Name, Description: file.Description}
+	elements := []struct {
+		key   string
+		style *elementStyle
+		field *ColorFunc
+	}{
+		{"header", file.Header, &scheme.Header},
+		{"subheader", file.Subheader, &scheme.Subheader},
+		{"command", file.Command, &scheme.Command},
+		{"label", file.Label, &scheme.Label},
+		{"value", file.Value, &scheme.Value},
+		{"progress", file.Progress, &scheme.Progress},
+		{"completed", file.Completed, &scheme.Completed},
+		{"error", file.Error, &scheme.Error},
+		{"comparison", file.Comparison, &scheme.Comparison},
+		{"faster", file.Faster, &scheme.Faster},
+		{"slower", file.Slower, &scheme.Slower},
+	}
+
+	var problems []string
+	for _, element := range elements {
+		switch {
+		case element.style == nil:
+			problems = append(problems, fmt.Sprintf("missing [%s] table", element.key))
+		case element.style.Color == "":
+			problems = append(problems, fmt.Sprintf("%s.color is required", element.key))
+		case !hexColor.MatchString(element.style.Color):
+			problems = append(problems, fmt.Sprintf("%s.color %q is not a hex color like \"#89b4fa\"", element.key, element.style.Color))
+		default:
+			*element.field = colorize(expandHex(element.style.Color), element.style.Bold)
+		}
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("color scheme %s: %s", path, strings.Join(problems, "; "))
+	}
+
+	return scheme, nil
+}
+
+// expandHex turns the short "#rgb" form into "#rrggbb"
+func expandHex(hex string) string {
+	if len(hex) != 4 {
+		return hex
+	}
+	return string([]byte{'#', hex[1], hex[1], hex[2], hex[2], hex[3], hex[3]})
+}
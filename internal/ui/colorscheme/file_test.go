@@ -0,0 +1,135 @@
+package colorscheme
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const validScheme = `
+name = "Test"
+description = "A test scheme"
+
+header = { color = "#cba6f7", bold = true }
+subheader = { color = "#b4befe" }
+command = { color = "#b4befe", bold = true }
+label = { color = "#74c7ec" }
+value = { color = "#cdd6f4" }
+progress = { color = "#94e2d5" }
+completed = { color = "#a6e3a1" }
+error = { color = "#f38ba8" }
+comparison = { color = "#cba6f7" }
+faster = { color = "#a6e3a1" }
+slower = { color = "#eba0ac" }
+`
+
+func writeScheme(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write scheme: %v", err)
+	}
+	return path
+}
+
+func TestLoadFile(t *testing.T) {
+	dir := t.TempDir()
+
+	scheme, err := LoadFile(writeScheme(t, dir, "test.toml", validScheme))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if scheme.Name != "Test" || scheme.Description != "A test scheme" {
+		t.Errorf("Unexpected name or description: %q, %q", scheme.Name, scheme.Description)
+	}
+	for name, fn := range map[string]ColorFunc{
+		"header": scheme.Header, "subheader": scheme.Subheader, "command": scheme.Command,
+		"label": scheme.Label, "value": scheme.Value, "progress": scheme.Progress,
+		"completed": scheme.Completed, "error": scheme.Error, "comparison": scheme.Comparison,
+		"faster": scheme.Faster, "slower": scheme.Slower,
+	} {
+		if fn == nil {
+			t.Errorf("%s has no color function", name)
+		} else if !strings.Contains(fn("text"), "text") {
+			t.Errorf("%s dropped its text: %q", name, fn("text"))
+		}
+	}
+
+	// The name defaults to the file name
+	short := strings.Replace(validScheme, `name = "Test"`, "", 1)
+	scheme, err = LoadFile(writeScheme(t, dir, "short.toml", short))
+	if err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	if scheme.Name != "short" {
+		t.Errorf("Name = %q, want %q", scheme.Name, "short")
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"syntax", "header = {", "line"},
+		{"unknown key", validScheme + "\nbackground = { color = \"#000000\" }", "unknown keys: background"},
+		{"bad color", strings.Replace(validScheme, `"#cdd6f4"`, `"blue"`, 1), `value.color "blue" is not a hex color`},
+		{"missing color", strings.Replace(validScheme, `{ color = "#74c7ec" }`, "{ bold = true }", 1), "label.color is required"},
+		{"missing element", strings.Replace(validScheme, `slower = { color = "#eba0ac" }`, "", 1), "missing [slower] table"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeScheme(t, dir, strings.ReplaceAll(tt.name, " ", "-")+".toml", tt.content)
+			_, err := LoadFile(path)
+			if err == nil {
+				t.Fatal("Expected an error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.want) || !strings.Contains(err.Error(), path) {
+				t.Errorf("Error %q should mention %q and the file", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserSchemes(t *testing.T) {
+	config := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("HOME", config)
+
+	dir, err := UserDir()
+	if err != nil {
+		t.Skipf("No user config dir: %v", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatalf("Failed to create %s: %v", dir, err)
+	}
+	writeScheme(t, dir, "mine.toml", validScheme)
+	writeScheme(t, dir, "broken.toml", "header = {")
+
+	names, err := ListUserSchemes()
+	if err != nil {
+		t.Fatalf("ListUserSchemes failed: %v", err)
+	}
+	if strings.Join(names, ",") != "broken,mine" {
+		t.Errorf("ListUserSchemes = %v, want [broken mine]", names)
+	}
+
+	if _, err := loadUserScheme("mine"); err != nil {
+		t.Errorf("loadUserScheme(mine) failed: %v", err)
+	}
+	if _, err := loadUserScheme("missing"); err == nil || !strings.Contains(err.Error(), "unknown color scheme") {
+		t.Errorf("loadUserScheme(missing) error = %v", err)
+	}
+
+	list := FormatSchemeList()
+	for _, want := range []string{"catppuccin", "mine: A test scheme", "broken: invalid"} {
+		if !strings.Contains(list, want) {
+			t.Errorf("FormatSchemeList missing %q:\n%s", want, list)
+		}
+	}
+}
@@ -1,9 +1,5 @@
 package colorscheme
 
-import (
-	"github.com/muesli/termenv"
-)
-
 // Catppuccin returns the Catppuccin color scheme (Mocha variant)
 func Catppuccin() *Scheme {
 	return &Scheme{
@@ -142,7 +138,7 @@ func Monochrome() *Scheme {
 	// Use plain text without any color styling
 	plain := func(s string) string { return s }
 	bold := func(s string) string {
-		return termenv.String(s).Bold().String()
+		return profile.String(s).Bold().String()
 	}
 
 	return &Scheme{
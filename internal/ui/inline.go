@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"math"
 	"strings"
 	"sync"
@@ -12,7 +13,9 @@ import (
 	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
 )
 
+// InlineUI is the live terminal UI, redrawn in place with cursor movement
 type InlineUI struct {
+	writer      io.Writer
 	commands    []*benchmark.CommandStats
 	totalRuns   int
 	duration    time.Duration
@@ -26,15 +29,95 @@ type InlineUI struct {
 	lastLines           int
 	lastProgressPercent float64
 	lastEta             time.Duration
+
+	// Keyboard control state, see keys.go. controller is only set once the
+	// keyboard is actually enabled, which needs stdin to be a terminal.
+	configuredCtrl  Controller
+	controller      Controller
+	interrupt       func()
+	restoreKeyboard func()
+	rawMode         bool
+	selected        int
+	extendBy        time.Duration
+	stopped         bool
+	pausedAt        time.Time
+	pausedTotal     time.Duration
 }
 
-func NewInlineUI(totalRuns int) *InlineUI {
+// NewInlineUI creates the live terminal UI. Keyboard controls are enabled on
+// Start when options.Controller is set.
+func NewInlineUI(writer io.Writer, options Options) (*InlineUI, error) {
+	scheme, err := resolveScheme(options.ColorScheme)
+	if err != nil {
+		return nil, err
+	}
+
 	return &InlineUI{
-		commands:   make([]*benchmark.CommandStats, 0),
-		totalRuns:  totalRuns,
-		startTime:  time.Now(),
-		lastUpdate: time.Now(),
+		writer:      writer,
+		commands:    make([]*benchmark.CommandStats, 0),
+		totalRuns:   options.Runs,
+		duration:    options.Duration,
+		extendBy:    options.Duration,
+		colorScheme: scheme,
+		startTime:   time.Now(),
+		lastUpdate:  time.Now(),
+		interrupt:   options.Interrupt,
+		// Set once the keyboard is enabled, by Start
+		restoreKeyboard: func() {},
+		configuredCtrl:  options.Controller,
+	}, nil
+}
+
+// Start resets the clock and enables keyboard controls if configured
+func (ui *InlineUI) Start() error {
+	ui.mu.Lock()
+	ui.startTime = time.Now()
+	ui.lastUpdate = ui.startTime
+	ctrl := ui.configuredCtrl
+	ui.mu.Unlock()
+
+	if ctrl == nil {
+		return nil
+	}
+
+	interrupt := ui.interrupt
+	if interrupt == nil {
+		interrupt = ctrl.Stop
+	}
+	restore, err := ui.enableKeyboard(ctrl, interrupt)
+	if err != nil {
+		return fmt.Errorf("failed to enable keyboard controls: %w", err)
+	}
+
+	ui.mu.Lock()
+	ui.restoreKeyboard = restore
+	ui.mu.Unlock()
+	return nil
+}
+
+// Finish restores the terminal and draws the final results, unless the
+// last update already did
+func (ui *InlineUI) Finish(stats []*benchmark.CommandStats) error {
+	ui.restore()
+
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if !ui.finished {
+		ui.commands = stats
+		ui.finished = true
+		ui.render()
 	}
+	return nil
+}
+
+// restore leaves raw mode if keyboard controls enabled it
+func (ui *InlineUI) restore() {
+	ui.mu.Lock()
+	restore := ui.restoreKeyboard
+	ui.mu.Unlock()
+
+	restore()
 }
 
 func (ui *InlineUI) Update(stats []*benchmark.CommandStats, complete bool) {
@@ -63,23 +146,57 @@ func (ui *InlineUI) Update(stats []*benchmark.CommandStats, complete bool) {
 	ui.render()
 }
 
-func (ui *InlineUI) EventHandler(event interface{}) {
+// refresh redraws the UI immediately, bypassing the update throttle
+func (ui *InlineUI) refresh() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if !ui.finished {
+		ui.render()
+	}
+}
+
+// setPaused freezes or unfreezes the elapsed time shown and used for progress
+func (ui *InlineUI) setPaused(paused bool) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if paused && ui.pausedAt.IsZero() {
+		ui.pausedAt = time.Now()
+	} else if !paused && !ui.pausedAt.IsZero() {
+		ui.pausedTotal += time.Since(ui.pausedAt)
+		ui.pausedAt = time.Time{}
+	}
+}
+
+// elapsed returns the time the benchmark has been running, excluding pauses
+func (ui *InlineUI) elapsed() time.Duration {
+	end := time.Now()
+	if !ui.pausedAt.IsZero() {
+		end = ui.pausedAt
+	}
+	return end.Sub(ui.startTime) - ui.pausedTotal
+}
+
+func (ui *InlineUI) Event(event benchmark.Event) {
 	// We don't need to handle individual events in this UI
 }
 
 func (ui *InlineUI) Cancel() {
+	ui.restore()
+
 	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
 	// Check if we're already finished to avoid duplicate output
 	if ui.finished {
-		ui.mu.Unlock()
 		return
 	}
 	ui.cancelled = true
 	ui.finished = true
-	ui.mu.Unlock()
 
-	// Trigger a final update with the current state
-	ui.Update(ui.commands, false) // Pass false for complete
+	// Draw the final state with the cancelled footer
+	ui.render()
 }
 
 func (ui *InlineUI) render() {
@@ -90,7 +207,7 @@ func (ui *InlineUI) render() {
 	for _, cmd := range ui.commands {
 		if cmd != nil {
 			totalCompleted += cmd.TotalRuns
-			totalExpected += ui.totalRuns
+			totalExpected += targetRuns(cmd, ui.totalRuns)
 		}
 	}
 
@@ -101,7 +218,7 @@ func (ui *InlineUI) render() {
 
 	if ui.duration > 0 {
 		// For duration-based benchmarks, calculate progress based on elapsed time
-		elapsed := time.Since(ui.startTime)
+		elapsed := ui.elapsed()
 		if elapsed >= ui.duration {
 			progressPercent = 1.0
 		} else {
@@ -128,7 +245,7 @@ func (ui *InlineUI) render() {
 			}
 
 			// Estimate progress based on elapsed time vs timeout
-			elapsed := time.Since(ui.startTime)
+			elapsed := ui.elapsed()
 			if elapsed < cmdTimeout {
 				// Estimate progress as a fraction of the timeout
 				// This gives a sense of progress even when no commands have completed
@@ -145,13 +262,15 @@ func (ui *InlineUI) render() {
 
 			for _, cmd := range ui.commands {
 				if cmd != nil && cmd.Command != nil {
+					targetRuns := targetRuns(cmd, ui.totalRuns)
+
 					// Skip completed commands
-					if cmd.TotalRuns >= ui.totalRuns {
+					if cmd.TotalRuns >= targetRuns {
 						continue
 					}
 
 					// Calculate progress for this command
-					cmdProgress := float64(cmd.TotalRuns) / float64(ui.totalRuns)
+					cmdProgress := float64(cmd.TotalRuns) / float64(targetRuns)
 
 					// Only calculate if we have some results
 					if cmd.SuccessfulRuns > 0 && cmdProgress > 0 {
@@ -159,7 +278,7 @@ func (ui *InlineUI) render() {
 						avgRunTime := float64(cmd.Mean.Nanoseconds()) / 1e9 // in seconds
 
 						// Calculate runs remaining for this command
-						runsRemaining := ui.totalRuns - cmd.TotalRuns
+						runsRemaining := targetRuns - cmd.TotalRuns
 
 						// Calculate total time remaining for this command
 						// Account for parallelism
@@ -269,7 +388,11 @@ func (ui *InlineUI) render() {
 
 	output.WriteString(headerColor("✨ cmdperf - Command Performance Benchmarking ✨\n"))
 	output.WriteString(strings.Repeat("─", sepWidth) + "\n")
-	output.WriteString(subheaderColor(fmt.Sprintf("Timer overhead: ~%s\n\n", formatDuration(benchmark.TimerOverhead()))))
+	output.WriteString(subheaderColor(fmt.Sprintf("Timer overhead: ~%s\n", formatDuration(benchmark.TimerOverhead()))))
+	for _, line := range shellOverheads(ui.commands) {
+		output.WriteString(subheaderColor(line + "\n"))
+	}
+	output.WriteString("\n")
 
 	// For very narrow terminals, hide columns progressively
 	if termWidth < 80 {
@@ -336,32 +459,52 @@ func (ui *InlineUI) render() {
 	output.WriteString(subheaderColor(headerLine))
 	output.WriteString(strings.Repeat("━", sepWidth) + "\n")
 
+	// Only mark the selection when there is something to choose between
+	showSelection := ui.controller != nil && len(ui.commands) > 1
+
 	// Print command progress
-	for _, cmd := range ui.commands {
+	for i, cmd := range ui.commands {
 		if cmd == nil || cmd.Command == nil {
 			continue
 		}
 
+		if showSelection {
+			if i == ui.selected {
+				output.WriteString(progressColor("▶ "))
+			} else {
+				output.WriteString("  ")
+			}
+		}
+
 		// Print command on its own line with color
 		cmdName := cmd.Command.Raw
 		if ui.duration > 0 {
-			output.WriteString(fmt.Sprintf("%s %s %s\n",
+			output.WriteString(fmt.Sprintf("%s %s %s",
 				labelColor("Command:"),
 				commandColor(cmdName),
 				subheaderColor(fmt.Sprintf("(running for %s)", ui.duration))))
+		} else if status := precisionStatus(cmd); status != "" {
+			output.WriteString(fmt.Sprintf("%s %s %s",
+				labelColor("Command:"),
+				commandColor(cmdName),
+				subheaderColor("("+status+")")))
 		} else {
-			output.WriteString(fmt.Sprintf("%s %s\n",
+			output.WriteString(fmt.Sprintf("%s %s",
 				labelColor("Command:"),
 				commandColor(cmdName)))
 		}
+		if cmd.Skipped {
+			output.WriteString(" " + cancelledColor("(skipped)"))
+		}
+		output.WriteString("\n")
 
 		var runs string
 		if ui.duration > 0 {
 			// When using duration mode, just show the total runs without a target
 			runs = fmt.Sprintf("%d", cmd.TotalRuns)
 		} else {
-			// When using iterations mode, show progress as X/Y
-			runs = fmt.Sprintf("%d/%d", cmd.TotalRuns, ui.totalRuns)
+			// When using iterations or precision mode, show progress as X/Y
+			runs = formatRuns(cmd, ui.totalRuns)
 		}
 
 		meanStdDev, timeRange, throughput := "-", "-", "-"
@@ -383,27 +526,14 @@ func (ui *InlineUI) render() {
 			}
 		}
 
-		// Format error count
+		// Format error count, broken down by how the runs failed
 		errorStr := "-"
 		if cmd.ErrorCount > 0 {
 			errorStr = fmt.Sprintf("%d", cmd.ErrorCount)
-		}
-
-		// Add timeout information
-		timeoutInfo := ""
-		for exitCode, count := range cmd.ExitCodes {
-			if exitCode != 0 {
-				// For duration-based benchmarks, don't show exit codes from context cancellation
-				if ui.duration > 0 && exitCode == -1 {
-					// Skip showing these exit codes
-					continue
-				}
-				timeoutInfo += fmt.Sprintf(" [Exit %d: %d]", exitCode, count)
+			if failures := formatOutcomesShort(cmd.Outcomes); failures != "" {
+				errorStr += " (" + failures + ")"
 			}
 		}
-		if cmd.ErrorCount > 0 {
-			errorStr = fmt.Sprintf("%d%s", cmd.ErrorCount, timeoutInfo)
-		}
 
 		// Create a formatted line with proper spacing using dynamic widths
 		lineArgs := []interface{}{}
@@ -435,10 +565,44 @@ func (ui *InlineUI) render() {
 		line := fmt.Sprintf(lineFormat, lineArgs...)
 		output.WriteString(valueColor(line))
 
+		// Mini charts: the latency distribution so far and per-second
+		// latency (and throughput, if there is room) over the recent past.
+		// Dropped on narrow terminals like the other optional columns.
+		if termWidth >= 60 && cmd.SuccessfulRuns >= 2 {
+			available := termWidth - 36
+			histWidth := min(40, available/2)
+			sparkWidth := min(30, available-histWidth)
+			samples, tput, latency := cmd.Charts(time.Now(), sparkWidth)
+
+			charts := fmt.Sprintf("  %s %s  %s %s",
+				labelColor("Distribution"),
+				progressColor(histogram(samples, histWidth)),
+				labelColor(fmt.Sprintf("Latency (%ds)", sparkWidth)),
+				progressColor(sparkline(durationsToFloats(latency))))
+			if available-histWidth-sparkWidth >= sparkWidth+14 {
+				charts += fmt.Sprintf("  %s %s",
+					labelColor("Throughput"),
+					progressColor(sparkline(tput)))
+			}
+			output.WriteString(charts + "\n")
+		}
+
+		if note := inShellNote(cmd); note != "" {
+			output.WriteString(fmt.Sprintf("  %s\n", labelColor(note)))
+		}
+
 		if cmd.HighVariance && cmd.Mean > 0 {
 			pct := float64(cmd.StdDev) / float64(cmd.Mean) * 100
 			output.WriteString(fmt.Sprintf("  %s\n",
-				cancelledColor(fmt.Sprintf("⚠ High variance (stddev %.0f%% of mean). Try more runs.", pct))))
+				cancelledColor(fmt.Sprintf("⚠ High variance (stddev %.0f%% of mean). %s", pct, varianceAdvice(cmd)))))
+		}
+
+		if warning := shellOverheadWarning(cmd); warning != "" {
+			output.WriteString(fmt.Sprintf("  %s\n", cancelledColor(warning)))
+		}
+
+		if validation := validationNote(cmd); validation != "" {
+			output.WriteString(fmt.Sprintf("  %s\n", cancelledColor("⚠ "+validation)))
 		}
 	}
 
@@ -452,7 +616,7 @@ func (ui *InlineUI) render() {
 		// For commands that haven't completed yet, show estimated progress
 		// with a pulsing indicator at the end of the progress bar
 		var pulseChar string
-		pulseIndex := int(time.Since(ui.startTime).Seconds()*2) % 3
+		pulseIndex := int(ui.elapsed().Seconds()*2) % 3
 		switch pulseIndex {
 		case 0:
 			pulseChar = "▒"
@@ -488,7 +652,7 @@ func (ui *InlineUI) render() {
 
 	// Print progress information at the bottom
 	output.WriteString(strings.Repeat("─", sepWidth) + "\n")
-	elapsed := time.Since(ui.startTime).Round(time.Second)
+	elapsed := ui.elapsed().Round(time.Second)
 
 	// Format the ETA string
 	etaStr := "calculating..."
@@ -497,7 +661,7 @@ func (ui *InlineUI) render() {
 	} else if progressPercent > 0 && !ui.cancelled && totalCompleted > 0 {
 		if ui.duration > 0 {
 			// For duration mode, format with only one fractional digit
-			remainingTime := ui.duration - time.Since(ui.startTime)
+			remainingTime := ui.duration - ui.elapsed()
 			if remainingTime < 0 {
 				remainingTime = 0
 			}
@@ -529,6 +693,8 @@ func (ui *InlineUI) render() {
 		if ui.cancelled {
 			// Only show cancelled message if explicitly cancelled
 			output.WriteString("\n" + cancelledColor("⚠️  Benchmark cancelled!") + "\n")
+		} else if ui.stopped {
+			output.WriteString("\n" + completedColor("⏹  Benchmark stopped early") + "\n")
 		} else if ui.duration > 0 {
 			// For duration-based benchmarks, always show completed
 			output.WriteString("\n" + completedColor("✅ Benchmark completed!") + "\n")
@@ -538,10 +704,28 @@ func (ui *InlineUI) render() {
 		} else {
 			output.WriteString("\n" + completedColor("✅ Benchmark completed!") + "\n")
 		}
+	} else if !ui.pausedAt.IsZero() {
+		output.WriteString("\n" + progressColor("⏸  Paused, press p to resume") + "\n")
+	} else if ui.controller != nil {
+		keys := "p pause · + extend · s skip · q stop · Ctrl+C interrupt"
+		if !ui.controller.CanExtend() {
+			keys = "p pause · s skip · q stop · Ctrl+C interrupt"
+		}
+		if showSelection {
+			keys = "Tab select · " + keys
+		}
+		output.WriteString("\n" + valueColor(keys) + "\n")
 	} else {
 		output.WriteString("\n" + valueColor("Press Ctrl+C to interrupt") + "\n")
 	}
 
+	// Raw mode turns off the terminal's newline translation
+	rendered := output.String()
+	lines := strings.Count(rendered, "\n")
+	if ui.rawMode {
+		rendered = strings.ReplaceAll(rendered, "\n", "\r\n")
+	}
+
 	// For the final render, don't clear previous output
 	// This ensures we don't get duplicate headers when cancelling
 	if ui.finished {
@@ -549,13 +733,13 @@ func (ui *InlineUI) render() {
 		// But first clear the previous output to avoid duplication
 		if ui.lastLines > 0 {
 			// Move cursor up by the number of lines we printed last time
-			fmt.Printf("\033[%dA", ui.lastLines)
+			fmt.Fprintf(ui.writer, "\033[%dA", ui.lastLines)
 			// Clear from cursor to end of screen
-			fmt.Print("\033[J")
+			fmt.Fprint(ui.writer, "\033[J")
 		}
 
 		// Print the final output
-		fmt.Print(output.String())
+		fmt.Fprint(ui.writer, rendered)
 
 		// Reset lastLines to avoid issues with future renders
 		ui.lastLines = 0
@@ -563,48 +747,15 @@ func (ui *InlineUI) render() {
 		// Normal update during benchmark - clear previous output
 		if ui.lastLines > 0 {
 			// Move cursor up by the number of lines we printed last time
-			fmt.Printf("\033[%dA", ui.lastLines)
+			fmt.Fprintf(ui.writer, "\033[%dA", ui.lastLines)
 			// Clear from cursor to end of screen
-			fmt.Print("\033[J")
+			fmt.Fprint(ui.writer, "\033[J")
 		}
 
 		// Print the new output
-		fmt.Print(output.String())
+		fmt.Fprint(ui.writer, rendered)
 
 		// Count the number of lines we just printed
-		ui.lastLines = strings.Count(output.String(), "\n")
-	}
-}
-
-func StartInlineUI(runs int, duration time.Duration, colorSchemeName string) error {
-	// Create an inline UI instance
-	ui := NewInlineUI(runs)
-	ui.duration = duration
-
-	// Set the color scheme
-	if colorSchemeName != "" {
-		scheme, err := colorscheme.GetScheme(colorSchemeName)
-		if err != nil {
-			return fmt.Errorf("invalid color scheme: %v (use --help to see available schemes)", err)
-		}
-		ui.colorScheme = scheme
-	} else {
-		// Use default scheme
-		ui.colorScheme = colorscheme.Default()
-	}
-
-	// Store the UI instance globally for the event handler
-	globalInlineUI = ui
-
-	return nil
-}
-
-// Global inline UI instance
-var globalInlineUI *InlineUI
-
-func GetGlobalInlineUI() (*InlineUI, bool) {
-	if globalInlineUI == nil {
-		return nil, false
+		ui.lastLines = lines
 	}
-	return globalInlineUI, true
 }
@@ -0,0 +1,161 @@
+package ui
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/output"
+)
+
+// DefaultJSONInterval is how often JSONLinesUI reports progress
+const DefaultJSONInterval = time.Second
+
+// JSONLinesUI reports progress as one JSON object per line, for tools that
+// follow a run. Every line has a "type": "start", "progress", "cancelled" or
+// "results"; the last carries the same per-command objects as --json.
+type JSONLinesUI struct {
+	encoder   *json.Encoder
+	totalRuns int
+	duration  time.Duration
+	interval  time.Duration
+
+	mu         sync.Mutex
+	startTime  time.Time
+	lastReport time.Time
+	finished   bool
+}
+
+type jsonLinesStart struct {
+	Type       string `json:"type"`
+	Time       string `json:"time"`
+	Runs       int    `json:"runs,omitempty"`
+	DurationNs int64  `json:"duration_ns,omitempty"`
+}
+
+type jsonLinesProgress struct {
+	Type      string                   `json:"type"`
+	ElapsedNs int64                    `json:"elapsed_ns"`
+	Commands  []jsonLinesCommandStatus `json:"commands"`
+}
+
+type jsonLinesCommandStatus struct {
+	Command        string `json:"command"`
+	TotalRuns      int    `json:"total_runs"`
+	TargetRuns     int    `json:"target_runs,omitempty"`
+	SuccessfulRuns int    `json:"successful_runs"`
+	ErrorCount     int    `json:"error_count"`
+	MeanNs         int64  `json:"mean_ns"`
+	StdDevNs       int64  `json:"stddev_ns"`
+	Skipped        bool   `json:"skipped,omitempty"`
+
+	// Runs by how they ended
+	Outcomes output.Outcomes `json:"outcomes"`
+
+	// Set in precision mode
+	Precision float64 `json:"precision,omitempty"`
+	Converged bool    `json:"converged,omitempty"`
+}
+
+type jsonLinesEvent struct {
+	Type    string          `json:"type"`
+	Results []output.Result `json:"results,omitempty"`
+}
+
+// NewJSONLinesUI creates a JSON-lines progress reporter. A zero
+// options.Interval uses DefaultJSONInterval.
+func NewJSONLinesUI(writer io.Writer, options Options) *JSONLinesUI {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultJSONInterval
+	}
+	return &JSONLinesUI{
+		encoder:   json.NewEncoder(writer),
+		totalRuns: options.Runs,
+		duration:  options.Duration,
+		interval:  interval,
+	}
+}
+
+func (ui *JSONLinesUI) Start() error {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	ui.startTime = time.Now()
+	ui.lastReport = ui.startTime
+
+	start := jsonLinesStart{
+		Type:       "start",
+		Time:       ui.startTime.Format(time.RFC3339Nano),
+		DurationNs: ui.duration.Nanoseconds(),
+	}
+	if ui.duration == 0 {
+		start.Runs = ui.totalRuns
+	}
+	return ui.encoder.Encode(start)
+}
+
+func (ui *JSONLinesUI) Update(stats []*benchmark.CommandStats, complete bool) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if ui.finished || complete || time.Since(ui.lastReport) < ui.interval {
+		return
+	}
+	ui.lastReport = time.Now()
+
+	progress := jsonLinesProgress{
+		Type:      "progress",
+		ElapsedNs: time.Since(ui.startTime).Nanoseconds(),
+		Commands:  make([]jsonLinesCommandStatus, 0, len(stats)),
+	}
+	for _, cmd := range stats {
+		if cmd == nil || cmd.Command == nil {
+			continue
+		}
+		status := jsonLinesCommandStatus{
+			Command:        cmd.Command.Raw,
+			TotalRuns:      cmd.TotalRuns,
+			SuccessfulRuns: cmd.SuccessfulRuns,
+			ErrorCount:     cmd.ErrorCount,
+			MeanNs:         cmd.Mean.Nanoseconds(),
+			StdDevNs:       cmd.StdDev.Nanoseconds(),
+			Skipped:        cmd.Skipped,
+			Outcomes:       output.NewOutcomes(cmd.Outcomes),
+			Precision:      cmd.Precision,
+			Converged:      cmd.Converged,
+		}
+		if ui.duration == 0 {
+			status.TargetRuns = targetRuns(cmd, ui.totalRuns)
+		}
+		progress.Commands = append(progress.Commands, status)
+	}
+	_ = ui.encoder.Encode(progress)
+}
+
+func (ui *JSONLinesUI) Event(event benchmark.Event) {
+	// Progress is reported from Update alone
+}
+
+func (ui *JSONLinesUI) Cancel() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if !ui.finished {
+		_ = ui.encoder.Encode(jsonLinesEvent{Type: "cancelled"})
+	}
+}
+
+// Finish writes the results line
+func (ui *JSONLinesUI) Finish(stats []*benchmark.CommandStats) error {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if ui.finished {
+		return nil
+	}
+	ui.finished = true
+	return ui.encoder.Encode(jsonLinesEvent{Type: "results", Results: output.NewResults(stats)})
+}
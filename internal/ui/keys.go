@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Controller is the part of the benchmark runner driven by keyboard controls
+type Controller interface {
+	Pause()
+	Resume()
+	Paused() bool
+	CanExtend() bool
+	Extend()
+	Skip(index int)
+	Stop()
+}
+
+const (
+	keyCtrlC  = 0x03
+	keyTab    = '\t'
+	keyEscape = 0x1b
+)
+
+// enableKeyboard puts the terminal in raw mode and handles key presses until
+// the returned restore function is called:
+//
+//	p      pause/resume all workers
+//	+      extend the run by the original number of runs or duration, unless
+//	       there is no limit to extend
+//	s      skip the selected command
+//	q      stop gracefully, keeping the results collected so far
+//	Tab    select the next command (also j/k and the arrow keys)
+//
+// Raw mode stops Ctrl+C from raising SIGINT, so interrupt is called instead.
+// If stdin is not a terminal the keyboard is left alone. After restore, keys
+// go to whatever reads stdin next, except on Windows, where the reader can't
+// be cancelled: it stays blocked until the next key press and drops it.
+func (ui *InlineUI) enableKeyboard(ctrl Controller, interrupt func()) (restore func(), err error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return func() {}, nil
+	}
+
+	keys, stopKeys, err := newKeyReader(fd)
+	if err != nil {
+		return nil, err
+	}
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		stopKeys()
+		keys.Close()
+		return nil, err
+	}
+
+	ui.mu.Lock()
+	ui.controller = ctrl
+	ui.rawMode = true
+	ui.mu.Unlock()
+
+	var once sync.Once
+	done := make(chan struct{})
+	restore = func() {
+		once.Do(func() {
+			close(done)
+			stopKeys()
+			ui.mu.Lock()
+			ui.rawMode = false
+			ui.mu.Unlock()
+			_ = term.Restore(fd, state)
+		})
+	}
+
+	go func() {
+		ui.readKeys(keys, done, interrupt)
+		keys.Close()
+	}()
+
+	return restore, nil
+}
+
+// readKeys dispatches key presses from r until done is closed
+func (ui *InlineUI) readKeys(r io.Reader, done <-chan struct{}, interrupt func()) {
+	reader := bufio.NewReader(r)
+	for {
+		key, err := reader.ReadByte()
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		switch key {
+		case keyCtrlC:
+			interrupt()
+		case keyEscape:
+			// Arrow keys arrive in one read as ESC [ A (up) and ESC [ B
+			// (down); a lone Escape has nothing buffered behind it
+			if reader.Buffered() < 2 {
+				continue
+			}
+			if next, _ := reader.Peek(2); next[0] == '[' {
+				arrow := next[1]
+				_, _ = reader.Discard(2)
+				switch arrow {
+				case 'A':
+					ui.handleKey('k')
+				case 'B':
+					ui.handleKey('j')
+				}
+			}
+		default:
+			ui.handleKey(key)
+		}
+	}
+}
+
+// handleKey applies a single key press and redraws the UI
+func (ui *InlineUI) handleKey(key byte) {
+	ui.mu.Lock()
+	ctrl := ui.controller
+	ui.mu.Unlock()
+
+	if ctrl == nil {
+		return
+	}
+
+	switch key {
+	case 'p', 'P':
+		if ctrl.Paused() {
+			ctrl.Resume()
+			ui.setPaused(false)
+		} else {
+			ctrl.Pause()
+			ui.setPaused(true)
+		}
+	case '+', '=':
+		if !ctrl.CanExtend() {
+			return
+		}
+		ctrl.Extend()
+		ui.mu.Lock()
+		if ui.duration > 0 {
+			ui.duration += ui.extendBy
+		}
+		ui.mu.Unlock()
+	case 's', 'S':
+		ui.mu.Lock()
+		selected := ui.selected
+		ui.mu.Unlock()
+		ctrl.Skip(selected)
+	case 'q', 'Q':
+		ui.mu.Lock()
+		ui.stopped = true
+		ui.mu.Unlock()
+		ctrl.Stop()
+	case keyTab, 'j':
+		ui.moveSelection(1)
+	case 'k':
+		ui.moveSelection(-1)
+	default:
+		return
+	}
+
+	ui.refresh()
+}
+
+func (ui *InlineUI) moveSelection(delta int) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if n := len(ui.commands); n > 0 {
+		ui.selected = ((ui.selected+delta)%n + n) % n
+	}
+}
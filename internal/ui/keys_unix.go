@@ -0,0 +1,60 @@
+//go:build !windows
+
+package ui
+
+import (
+	"io"
+
+	"golang.org/x/sys/unix"
+)
+
+// keyReader reads the terminal at fd, waiting for input with poll rather than
+// in a read, so that stopping it leaves keys typed afterwards to whatever
+// reads the terminal next
+type keyReader struct {
+	fd    int
+	stopR int // readable once stopW is closed
+	stopW int
+}
+
+// newKeyReader returns a reader of the terminal at fd. stop makes its Read
+// return io.EOF without reading any further; Close releases it once its
+// reader returned.
+func newKeyReader(fd int) (keys io.ReadCloser, stop func(), err error) {
+	var pipe [2]int
+	if err := unix.Pipe(pipe[:]); err != nil {
+		return nil, nil, err
+	}
+	k := &keyReader{fd: fd, stopR: pipe[0], stopW: pipe[1]}
+	return k, func() { _ = unix.Close(k.stopW) }, nil
+}
+
+func (k *keyReader) Read(p []byte) (int, error) {
+	for {
+		fds := []unix.PollFd{
+			{Fd: int32(k.fd), Events: unix.POLLIN},
+			{Fd: int32(k.stopR), Events: unix.POLLIN},
+		}
+		if _, err := unix.Poll(fds, -1); err == unix.EINTR {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		switch {
+		case fds[1].Revents != 0:
+			return 0, io.EOF
+		case fds[0].Revents&(unix.POLLIN|unix.POLLHUP) != 0:
+			n, err := unix.Read(k.fd, p)
+			if n <= 0 && err == nil {
+				return 0, io.EOF
+			}
+			return max(n, 0), err
+		case fds[0].Revents != 0:
+			return 0, io.EOF
+		}
+	}
+}
+
+func (k *keyReader) Close() error {
+	return unix.Close(k.stopR)
+}
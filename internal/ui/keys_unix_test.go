@@ -0,0 +1,54 @@
+//go:build !windows
+
+package ui
+
+import (
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestKeyReaderStop(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	keys, stop, err := newKeyReader(int(r.Fd()))
+	if err != nil {
+		t.Fatalf("newKeyReader failed: %v", err)
+	}
+	defer keys.Close()
+
+	buf := make([]byte, 8)
+	w.Write([]byte("p"))
+	if n, err := keys.Read(buf); n != 1 || err != nil || buf[0] != 'p' {
+		t.Fatalf("Read = %d, %v (%q), want p", n, err, buf[:n])
+	}
+
+	// A read waiting for a key returns once stopped
+	read := make(chan error, 1)
+	go func() {
+		_, err := keys.Read(buf)
+		read <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	stop()
+	select {
+	case err := <-read:
+		if err != io.EOF {
+			t.Errorf("Read after stop = %v, want io.EOF", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Read still blocked after stop")
+	}
+
+	// Keys typed afterwards are left for the next reader
+	w.Write([]byte("x"))
+	if n, err := r.Read(buf); n != 1 || err != nil || buf[0] != 'x' {
+		t.Errorf("Next reader got %d, %v (%q), want x", n, err, buf[:n])
+	}
+}
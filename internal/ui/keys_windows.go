@@ -0,0 +1,15 @@
+//go:build windows
+
+package ui
+
+import (
+	"io"
+	"os"
+)
+
+// newKeyReader returns stdin, since console reads can't be waited for and
+// cancelled like on Unix. stop does nothing: the reader stays blocked until
+// the next key press, which it drops, see enableKeyboard.
+func newKeyReader(fd int) (keys io.ReadCloser, stop func(), err error) {
+	return io.NopCloser(os.Stdin), func() {}, nil
+}
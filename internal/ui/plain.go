@@ -0,0 +1,212 @@
+package ui
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/output"
+	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
+)
+
+// DefaultPlainInterval is how often PlainUI reports progress
+const DefaultPlainInterval = 10 * time.Second
+
+// plainMilestone is the progress step that triggers a report between
+// intervals, and plainMinGap keeps fast benchmarks from flooding the log
+const (
+	plainMilestone = 0.10
+	plainMinGap    = time.Second
+)
+
+// PlainUI reports progress as plain lines without cursor movement, for CI logs
+// and other output that isn't a terminal. It prints one line per command every
+// interval or progress milestone, followed by the full results at the end.
+type PlainUI struct {
+	writer    io.Writer
+	totalRuns int
+	duration  time.Duration
+	interval  time.Duration
+	quiet     bool
+	scheme    *colorscheme.Scheme
+	startTime time.Time
+
+	mu            sync.Mutex
+	commands      []*benchmark.CommandStats
+	lastReport    time.Time
+	nextMilestone float64
+	finished      bool
+	cancelled     bool
+}
+
+// NewPlainUI creates a plain progress reporter. A zero options.Interval uses
+// DefaultPlainInterval. options.ColorScheme colors the final results.
+func NewPlainUI(writer io.Writer, options Options) (*PlainUI, error) {
+	scheme, err := resolveScheme(options.ColorScheme)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultPlainInterval
+	}
+	now := time.Now()
+	return &PlainUI{
+		writer:        writer,
+		totalRuns:     options.Runs,
+		duration:      options.Duration,
+		interval:      interval,
+		scheme:        scheme,
+		startTime:     now,
+		lastReport:    now,
+		nextMilestone: plainMilestone,
+	}, nil
+}
+
+// NewQuietUI creates a reporter that prints only the final results
+func NewQuietUI(writer io.Writer, options Options) (*PlainUI, error) {
+	ui, err := NewPlainUI(writer, options)
+	if err != nil {
+		return nil, err
+	}
+	ui.quiet = true
+	return ui, nil
+}
+
+// Start resets the clock progress is measured from
+func (ui *PlainUI) Start() error {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	ui.startTime = time.Now()
+	ui.lastReport = ui.startTime
+	return nil
+}
+
+func (ui *PlainUI) Update(stats []*benchmark.CommandStats, complete bool) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	// The final results are printed by Finish
+	if ui.finished || complete || ui.quiet {
+		return
+	}
+	ui.commands = stats
+
+	progress := ui.progress()
+	sinceLast := time.Since(ui.lastReport)
+	milestone := progress >= ui.nextMilestone && sinceLast >= plainMinGap
+	if sinceLast < ui.interval && !milestone {
+		return
+	}
+
+	for ui.nextMilestone <= progress {
+		ui.nextMilestone += plainMilestone
+	}
+	ui.lastReport = time.Now()
+	ui.writeProgress(progress)
+}
+
+func (ui *PlainUI) Event(event benchmark.Event) {
+	// Progress is reported from Update alone
+}
+
+// Cancel notes the interruption; the partial results are still printed by
+// Finish
+func (ui *PlainUI) Cancel() {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if ui.finished || ui.cancelled {
+		return
+	}
+	ui.cancelled = true
+	fmt.Fprintln(ui.writer, "Benchmark cancelled, reporting partial results")
+}
+
+// progress returns overall completion between 0 and 1
+func (ui *PlainUI) progress() float64 {
+	if ui.duration > 0 {
+		return minFloat(1, float64(time.Since(ui.startTime))/float64(ui.duration))
+	}
+
+	completed, expected := 0, 0
+	for _, cmd := range ui.commands {
+		if cmd != nil {
+			completed += cmd.TotalRuns
+			expected += targetRuns(cmd, ui.totalRuns)
+		}
+	}
+	if expected == 0 {
+		return 0
+	}
+	return minFloat(1, float64(completed)/float64(expected))
+}
+
+func (ui *PlainUI) writeProgress(progress float64) {
+	elapsed := time.Since(ui.startTime).Round(time.Second)
+
+	for _, cmd := range ui.commands {
+		if cmd == nil || cmd.Command == nil {
+			continue
+		}
+
+		var line strings.Builder
+		fmt.Fprintf(&line, "[%s] %s: ", elapsed, cmd.Command.Raw)
+		if ui.duration > 0 {
+			fmt.Fprintf(&line, "%d runs (%.0f%%)", cmd.TotalRuns, progress*100)
+		} else {
+			target := targetRuns(cmd, ui.totalRuns)
+			pct := 0.0
+			if target > 0 {
+				pct = float64(cmd.TotalRuns) / float64(target) * 100
+			}
+			fmt.Fprintf(&line, "%s runs (%.0f%%)", formatRuns(cmd, ui.totalRuns), pct)
+		}
+		if status := precisionStatus(cmd); status != "" {
+			fmt.Fprintf(&line, ", precision %s", status)
+		}
+
+		if cmd.SuccessfulRuns > 0 {
+			fmt.Fprintf(&line, ", mean %s ± %s, range %s … %s",
+				formatDuration(cmd.Mean), formatDuration(cmd.StdDev),
+				formatDuration(cmd.Min), formatDuration(cmd.Max))
+		}
+		if cmd.ErrorCount > 0 {
+			fmt.Fprintf(&line, ", %d errors", cmd.ErrorCount)
+			if failures := formatOutcomesShort(cmd.Outcomes); failures != "" {
+				fmt.Fprintf(&line, " (%s)", failures)
+			}
+		}
+		if cmd.Skipped {
+			line.WriteString(", skipped")
+		}
+
+		fmt.Fprintln(ui.writer, line.String())
+	}
+}
+
+// Finish prints the full results
+func (ui *PlainUI) Finish(stats []*benchmark.CommandStats) error {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+
+	if ui.finished {
+		return nil
+	}
+	ui.finished = true
+
+	terminalWriter := &output.TerminalWriter{Scheme: ui.scheme}
+	return terminalWriter.Write(ui.writer, stats)
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
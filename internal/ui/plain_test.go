@@ -0,0 +1,82 @@
+package ui
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+func TestPlainUI(t *testing.T) {
+	stats := []*benchmark.CommandStats{{
+		Command:        &command.Command{Raw: "echo hello"},
+		TargetRuns:     10,
+		TotalRuns:      5,
+		SuccessfulRuns: 5,
+		Mean:           2 * time.Millisecond,
+		ExitCodes:      map[int]int{0: 5},
+	}}
+
+	var buf bytes.Buffer
+	plain, err := NewPlainUI(&buf, Options{Runs: 10, Interval: time.Hour})
+	if err != nil {
+		t.Fatalf("NewPlainUI failed: %v", err)
+	}
+	if err := plain.Start(); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	plain.Update(stats, false)
+	if buf.Len() != 0 {
+		t.Errorf("Reported progress before the interval or a milestone:\n%s", buf.String())
+	}
+
+	plain.lastReport = time.Now().Add(-2 * time.Second)
+	plain.Update(stats, false)
+	if !strings.Contains(buf.String(), "echo hello: 5/10 runs (50%), mean 2.00 ms") {
+		t.Errorf("Milestone progress line missing, got:\n%s", buf.String())
+	}
+	if strings.Contains(buf.String(), "\033[") {
+		t.Errorf("Plain output contains escape sequences:\n%q", buf.String())
+	}
+
+	buf.Reset()
+	stats[0].TotalRuns = 10
+	plain.Update(stats, true)
+	if err := plain.Finish(stats); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Command:") {
+		t.Errorf("Final results missing, got:\n%s", buf.String())
+	}
+}
+
+func TestQuietUI(t *testing.T) {
+	stats := []*benchmark.CommandStats{{
+		Command:   &command.Command{Raw: "echo hello"},
+		TotalRuns: 5,
+		ExitCodes: map[int]int{0: 5},
+	}}
+
+	var buf bytes.Buffer
+	quiet, err := NewQuietUI(&buf, Options{})
+	if err != nil {
+		t.Fatalf("NewQuietUI failed: %v", err)
+	}
+	quiet.lastReport = time.Time{}
+	quiet.Update(stats, false)
+	if buf.Len() != 0 {
+		t.Errorf("Quiet UI reported progress:\n%s", buf.String())
+	}
+
+	quiet.Update(stats, true)
+	if err := quiet.Finish(stats); err != nil {
+		t.Fatalf("Finish failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), "echo hello") {
+		t.Errorf("Quiet UI did not print the final results, got:\n%s", buf.String())
+	}
+}
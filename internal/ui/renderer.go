@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/ui/colorscheme"
+)
+
+// Renderer displays a benchmark's progress and final results
+type Renderer interface {
+	// Start is called once, right before the benchmark begins
+	Start() error
+
+	// Update receives the latest statistics for every command. It is called
+	// often and from several goroutines; complete is true for the last call.
+	Update(stats []*benchmark.CommandStats, complete bool)
+
+	// Event receives individual benchmark events, see Runner.SetEventHandler
+	Event(event benchmark.Event)
+
+	// Cancel marks the benchmark as interrupted. It may be called from a
+	// signal handler at any point, including after Finish.
+	Cancel()
+
+	// Finish is called once after the benchmark ends, with the final
+	// statistics, and prints the results
+	Finish(stats []*benchmark.CommandStats) error
+}
+
+// Options configures a Renderer
+type Options struct {
+	// Runs and Duration mirror benchmark.Options and drive progress and ETA.
+	// Both are zero in precision mode, where every command's TargetRuns is
+	// its own estimate.
+	Runs     int
+	Duration time.Duration
+
+	// ColorScheme is the color scheme's name or file, see colorscheme.GetScheme
+	ColorScheme string
+
+	// Interval between progress reports for the plain and JSON renderers.
+	// Zero uses the renderer's default.
+	Interval time.Duration
+
+	// Controller enables the inline UI's keyboard controls when set
+	Controller Controller
+
+	// Interrupt is called when Ctrl+C is pressed while keyboard controls
+	// are active, since the terminal no longer raises SIGINT
+	Interrupt func()
+}
+
+// Renderer modes accepted by NewRenderer
+const (
+	ModeInline = "inline"
+	ModePlain  = "plain"
+	ModeJSON   = "json"
+	ModeNone   = "none"
+)
+
+// NewRenderer creates the renderer for mode, writing to writer
+func NewRenderer(mode string, writer io.Writer, options Options) (Renderer, error) {
+	switch mode {
+	case ModeInline:
+		return NewInlineUI(writer, options)
+	case ModePlain:
+		return NewPlainUI(writer, options)
+	case ModeJSON:
+		return NewJSONLinesUI(writer, options), nil
+	case ModeNone:
+		return NewQuietUI(writer, options)
+	default:
+		return nil, fmt.Errorf("unknown progress mode: %s", mode)
+	}
+}
+
+// resolveScheme returns the color scheme called name, or the default one when
+// name is empty
+func resolveScheme(name string) (*colorscheme.Scheme, error) {
+	if name == "" {
+		return colorscheme.Default(), nil
+	}
+	scheme, err := colorscheme.GetScheme(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid color scheme: %v (use --list-color-schemes to see available schemes)", err)
+	}
+	return scheme, nil
+}
+
+// Run drives runner to completion, reporting to renderer. Events are also
+// passed to every observer, in order.
+func Run(ctx context.Context, runner *benchmark.Runner, renderer Renderer, observers ...func(benchmark.Event)) error {
+	if err := renderer.Start(); err != nil {
+		return err
+	}
+
+	handlers := append([]func(benchmark.Event){renderer.Event}, observers...)
+	runner.SetProgressCallback(renderer.Update)
+	runner.SetEventHandler(func(event benchmark.Event) {
+		for _, handle := range handlers {
+			handle(event)
+		}
+	})
+	runner.Run(ctx)
+
+	return renderer.Finish(runner.Results)
+}
@@ -0,0 +1,104 @@
+package ui_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+	"github.com/miklosn/cmdperf/internal/ui"
+)
+
+// fakeRenderer records the calls a Renderer receives
+type fakeRenderer struct {
+	mu       sync.Mutex
+	calls    []string
+	updates  int
+	events   int
+	finished []*benchmark.CommandStats
+}
+
+func (f *fakeRenderer) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.calls) == 0 || f.calls[len(f.calls)-1] != call {
+		f.calls = append(f.calls, call)
+	}
+}
+
+func (f *fakeRenderer) Start() error {
+	f.record("start")
+	return nil
+}
+
+func (f *fakeRenderer) Update(stats []*benchmark.CommandStats, complete bool) {
+	f.record("update")
+	f.mu.Lock()
+	f.updates++
+	f.mu.Unlock()
+}
+
+func (f *fakeRenderer) Event(event benchmark.Event) {
+	f.mu.Lock()
+	f.events++
+	f.mu.Unlock()
+}
+
+func (f *fakeRenderer) Cancel() {
+	f.record("cancel")
+}
+
+func (f *fakeRenderer) Finish(stats []*benchmark.CommandStats) error {
+	f.record("finish")
+	f.finished = stats
+	return nil
+}
+
+func TestRunDrivesRenderer(t *testing.T) {
+	runner, err := benchmark.NewRunner([]*command.Command{{
+		Raw:          "true",
+		Shell:        "/bin/sh",
+		ShellOptions: []string{"-c"},
+		Parallelism:  1,
+		Timeout:      time.Second,
+	}}, benchmark.Options{Iterations: 3, Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Failed to create runner: %v", err)
+	}
+
+	renderer := &fakeRenderer{}
+	if err := ui.Run(context.Background(), runner, renderer); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	expected := []string{"start", "update", "finish"}
+	if len(renderer.calls) != len(expected) {
+		t.Fatalf("Renderer calls = %v, want %v", renderer.calls, expected)
+	}
+	for i := range expected {
+		if renderer.calls[i] != expected[i] {
+			t.Fatalf("Renderer calls = %v, want %v", renderer.calls, expected)
+		}
+	}
+
+	if renderer.events == 0 {
+		t.Error("Renderer received no events")
+	}
+	if len(renderer.finished) != 1 || renderer.finished[0].TotalRuns != 3 {
+		t.Errorf("Finish did not receive the final results: %+v", renderer.finished)
+	}
+}
+
+func TestNewRenderer(t *testing.T) {
+	for _, mode := range []string{ui.ModeInline, ui.ModePlain, ui.ModeJSON, ui.ModeNone} {
+		if _, err := ui.NewRenderer(mode, nil, ui.Options{Runs: 1}); err != nil {
+			t.Errorf("NewRenderer(%q) unexpected error: %v", mode, err)
+		}
+	}
+
+	if _, err := ui.NewRenderer("fancy", nil, ui.Options{}); err == nil {
+		t.Error("NewRenderer(\"fancy\") expected error, got nil")
+	}
+}
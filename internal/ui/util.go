@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"fmt"
 	"os"
 	"time"
 
+	"github.com/miklosn/cmdperf/internal/benchmark"
 	"github.com/miklosn/cmdperf/internal/output"
 	"golang.org/x/term"
 )
@@ -20,6 +22,66 @@ func formatThroughput(throughput float64) string {
 	return output.FormatThroughput(throughput)
 }
 
+func formatOutcomesShort(outcomes benchmark.Outcomes) string {
+	return output.FormatOutcomesShort(outcomes)
+}
+
+func varianceAdvice(cmd *benchmark.CommandStats) string {
+	return output.VarianceAdvice(cmd)
+}
+
+func shellOverheads(stats []*benchmark.CommandStats) []string {
+	return output.ShellOverheads(stats)
+}
+
+func inShellNote(cmd *benchmark.CommandStats) string {
+	return output.InShellNote(cmd)
+}
+
+func validationNote(cmd *benchmark.CommandStats) string {
+	return output.FormatValidation(cmd)
+}
+
+func shellOverheadWarning(cmd *benchmark.CommandStats) string {
+	return output.ShellOverheadWarning(cmd)
+}
+
+// targetRuns returns the number of runs cmd is expected to reach in
+// iteration mode, falling back to the configured runs
+func targetRuns(cmd *benchmark.CommandStats, runs int) int {
+	if cmd.Skipped {
+		return cmd.TotalRuns
+	}
+	if cmd.TargetRuns > 0 {
+		return cmd.TargetRuns
+	}
+	return runs
+}
+
+// precisionStatus describes how close cmd is to its target precision, or
+// returns "" outside precision mode
+func precisionStatus(cmd *benchmark.CommandStats) string {
+	switch {
+	case cmd.TargetPrecision <= 0:
+		return ""
+	case cmd.Converged:
+		return fmt.Sprintf("converged at ±%.2f%%", cmd.Precision*100)
+	case cmd.Precision > 0:
+		return fmt.Sprintf("±%.2f%% of ±%.2f%%", cmd.Precision*100, cmd.TargetPrecision*100)
+	default:
+		return fmt.Sprintf("target ±%.2f%%", cmd.TargetPrecision*100)
+	}
+}
+
+// formatRuns shows cmd's runs against its target, marking targets that are
+// still an estimate in precision mode
+func formatRuns(cmd *benchmark.CommandStats, runs int) string {
+	if cmd.TargetPrecision > 0 && !cmd.Converged && !cmd.Skipped {
+		return fmt.Sprintf("%d/~%d", cmd.TotalRuns, targetRuns(cmd, runs))
+	}
+	return fmt.Sprintf("%d/%d", cmd.TotalRuns, targetRuns(cmd, runs))
+}
+
 func getTerminalWidth() int {
 	width, _, err := term.GetSize(int(os.Stdout.Fd()))
 	if err != nil || width <= 0 {
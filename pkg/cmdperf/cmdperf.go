@@ -0,0 +1,259 @@
+// Package cmdperf benchmarks commands from Go, the way the cmdperf CLI does.
+//
+//	report, err := cmdperf.Benchmark(ctx,
+//		[]cmdperf.Command{{Line: "mytool --flag"}},
+//		cmdperf.Runs(50), cmdperf.Concurrency(4))
+//
+// Commands run through a shell by default, exactly like on the command line.
+// A Command with an Executor runs Go code instead, which is handy for
+// benchmarking in-process clients of a service, and HTTP, TCP and Unix send
+// requests to a local service without a process per run.
+//
+// The Report mirrors the CLI's --json output, and the built-in writers
+// produce the same output as the CLI's.
+package cmdperf
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+	"github.com/miklosn/cmdperf/internal/setup"
+)
+
+// Command is a workload to benchmark
+type Command struct {
+	// Line is the command line to run, and names the command in the Report
+	Line string
+
+	// Executor, when set, is run for every iteration instead of Line
+	Executor Executor
+
+	// target creates a built-in executor's command, see HTTP
+	target func() (*command.Command, error)
+}
+
+// Executor runs one iteration of a workload. Returning an error marks the
+// run as failed; errors with an ExitCode() int method, such as
+// *exec.ExitError, record that exit code and others record 1.
+type Executor interface {
+	Execute(ctx context.Context) error
+}
+
+// ExecutorFunc adapts a function to an Executor
+type ExecutorFunc func(ctx context.Context) error
+
+// Execute calls f(ctx)
+func (f ExecutorFunc) Execute(ctx context.Context) error {
+	return f(ctx)
+}
+
+// Func returns a Command named name that runs fn for every iteration
+func Func(name string, fn func(ctx context.Context) error) Command {
+	return Command{Line: name, Executor: ExecutorFunc(fn)}
+}
+
+// HTTP returns a Command that sends a GET request to url for every
+// iteration, reusing connections between them. Responses with a status of
+// 400 and above fail, recording the status as the exit code. The Result has
+// the Phases of the requests.
+func HTTP(url string) Command {
+	return Command{Line: url, target: func() (*command.Command, error) { return command.NewHTTP(url) }}
+}
+
+// HTTPOptions configure the request of HTTPRequest
+type HTTPOptions struct {
+	Header http.Header
+	Body   []byte
+
+	// NoKeepAlive opens a new connection for every iteration
+	NoKeepAlive bool
+
+	// ExpectStatus lists the statuses of successful iterations; without,
+	// statuses below 400 are
+	ExpectStatus []int
+}
+
+// HTTPRequest returns a Command that sends a method request to url for
+// every iteration, like HTTP. The Result has the Phases of the requests.
+func HTTPRequest(method, url string, options HTTPOptions) Command {
+	name := url
+	if method != http.MethodGet {
+		name = method + " " + url
+	}
+	return Command{Line: name, target: func() (*command.Command, error) {
+		return command.NewHTTPRequest(method, url, command.HTTPOptions(options))
+	}}
+}
+
+// TCP returns a Command that connects to address, a host and port, for every
+// iteration. With send, it sends it once connected and waits for the first
+// bytes of the response.
+func TCP(address string, send []byte) Command {
+	return Command{Line: address, target: func() (*command.Command, error) { return command.NewTCP(address, send) }}
+}
+
+// Unix returns a Command that connects to the Unix socket at path for every
+// iteration, sending send like TCP
+func Unix(path string, send []byte) Command {
+	return Command{Line: path, target: func() (*command.Command, error) { return command.NewUnix(path, send) }}
+}
+
+// Benchmark runs every command and returns their statistics. Commands run
+// concurrently with each other, as in the CLI.
+//
+// If ctx is cancelled the partial report is returned along with ctx's error.
+// Errors from the Outputs are returned after all of them have been written.
+func Benchmark(ctx context.Context, commands []Command, options ...Option) (*Report, error) {
+	opts := Options{}
+	for _, option := range options {
+		option.apply(&opts)
+	}
+	opts.setDefaults()
+
+	config := opts.config()
+	cmds := make([]*command.Command, len(commands))
+	for i, c := range commands {
+		cmd, err := opts.command(config, c)
+		if err != nil {
+			return nil, fmt.Errorf("cmdperf: command %d: %w", i+1, err)
+		}
+		cmds[i] = cmd
+	}
+
+	runner, cleanup, err := config.NewRunner(cmds)
+	if err != nil {
+		return nil, fmt.Errorf("cmdperf: %w", err)
+	}
+	defer cleanup()
+	runner.Run(ctx)
+
+	// Results are released to the pool by the CLI; a library run drops them
+	for _, stats := range runner.Results {
+		stats.RecentResults = nil
+	}
+
+	report := newReport(runner.Results)
+	if runner.Options.Schedule == benchmark.ScheduleRandom {
+		report.Seed = runner.Options.Seed
+	}
+
+	var errs []error
+	if err := runner.FailuresErr(); err != nil {
+		errs = append(errs, fmt.Errorf("cmdperf: saving failed runs: %w", err))
+	}
+	for _, out := range opts.Outputs {
+		if err := out.Writer.Write(out.W, report); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if ctx.Err() != nil {
+		errs = append([]error{ctx.Err()}, errs...)
+	}
+	return report, errors.Join(errs...)
+}
+
+// config converts o to the configuration the CLI's flags also build
+func (o *Options) config() *setup.Config {
+	config := &setup.Config{
+		Runner: benchmark.Options{
+			Iterations:  o.Runs,
+			Parallelism: o.Concurrency,
+			Timeout:     o.Timeout,
+			Duration:    o.Duration,
+			Rate:        o.Rate,
+			Outliers:    o.Outliers,
+			Schedule:    o.Schedule,
+			Seed:        o.Seed,
+			Percentiles: o.Percentiles,
+
+			SubtractShellOverhead: o.SubtractShellOverhead,
+
+			TargetPrecision: o.TargetPrecision,
+			MinRuns:         o.MinRuns,
+			MaxRuns:         o.MaxRuns,
+			MaxTime:         o.MaxTime,
+
+			FailuresDir:   o.FailuresDir,
+			FailuresLimit: o.FailuresLimit,
+		},
+
+		Shell:           o.Shell,
+		ShellOptions:    o.ShellOptions,
+		NoShell:         o.NoShell,
+		PersistentShell: o.PersistentShell,
+
+		KillSignal: o.KillSignal,
+		KillGrace:  o.KillGrace,
+
+		Input:             o.Input,
+		InputFrom:         o.InputFrom,
+		IncludeInputWrite: o.IncludeInputWrite,
+
+		CaptureOutput: o.CaptureOutput,
+		ExpectStdout:  o.ExpectStdout,
+	}
+	if len(o.ExpectExit) > 0 {
+		config.ExpectExit = [][]int{o.ExpectExit}
+	}
+	return config
+}
+
+// command converts c to the runner's representation
+func (o *Options) command(config *setup.Config, c Command) (*command.Command, error) {
+	switch {
+	case c.target != nil:
+		return c.target()
+	case c.Executor != nil:
+		cmd := &command.Command{Raw: c.Line}
+		cmd.Executor = &executorAdapter{executor: c.Executor, cmd: cmd, timeout: o.Timeout}
+		return cmd, nil
+	}
+	return config.Command(c.Line)
+}
+
+// executorAdapter runs a public Executor as a command.Executor
+type executorAdapter struct {
+	executor Executor
+	cmd      *command.Command
+	timeout  time.Duration
+}
+
+func (a *executorAdapter) Execute(ctx context.Context) *command.Result {
+	result := &command.Result{Command: a.cmd, StartTime: time.Now()}
+	if ctx.Err() != nil {
+		result.Error = ctx.Err()
+		result.ContextCancelled = true
+		return result
+	}
+
+	runCtx := ctx
+	if a.timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, a.timeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	err := a.executor.Execute(runCtx)
+	result.Duration = time.Since(startTime)
+
+	if err != nil {
+		result.Error = err
+		result.ExitCode = 1
+		var exitCoder interface{ ExitCode() int }
+		if errors.As(err, &exitCoder) {
+			result.ExitCode = exitCoder.ExitCode()
+		}
+		if ctx.Err() != nil {
+			result.ContextCancelled = true
+		} else if runCtx.Err() == context.DeadlineExceeded {
+			result.TimedOut = true
+		}
+	}
+	return result
+}
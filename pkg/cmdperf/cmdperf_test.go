@@ -0,0 +1,449 @@
+package cmdperf_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+	"github.com/miklosn/cmdperf/internal/output"
+	"github.com/miklosn/cmdperf/pkg/cmdperf"
+)
+
+type exitError int
+
+func (e exitError) Error() string { return "exit" }
+func (e exitError) ExitCode() int { return int(e) }
+
+func TestBenchmark(t *testing.T) {
+	var calls atomic.Int32
+	var buf bytes.Buffer
+
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{
+			{Line: "true"},
+			cmdperf.Func("in-process", func(ctx context.Context) error {
+				if calls.Add(1)%2 == 0 {
+					return exitError(3)
+				}
+				return nil
+			}),
+		},
+		cmdperf.Runs(4),
+		cmdperf.Concurrency(2),
+		cmdperf.WriteTo(&buf, cmdperf.Terminal),
+	)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	if report.Version != cmdperf.ReportVersion || len(report.Results) != 2 {
+		t.Fatalf("Unexpected report: %+v", report)
+	}
+	if shell := report.Results[0]; shell.Command != "true" || shell.TotalRuns != 4 || shell.ErrorCount != 0 || shell.Mean <= 0 {
+		t.Errorf("Unexpected shell result: %+v", shell)
+	}
+	if fn := report.Results[1]; fn.TotalRuns != 4 || fn.ErrorCount != 2 || fn.ExitCodes[3] != 2 {
+		t.Errorf("Unexpected function result: %+v", fn)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("Function ran %d times, want 4", calls.Load())
+	}
+	if report.Fastest() == nil {
+		t.Error("Fastest returned nil")
+	}
+	if !strings.Contains(buf.String(), "in-process") {
+		t.Errorf("Terminal output missing the command:\n%s", buf.String())
+	}
+}
+
+func TestReportFastest(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "sleep 0.01"}, {Line: "/nonexistent/cmdperf-test"}},
+		cmdperf.Runs(3), cmdperf.NoShell())
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if fastest := report.Fastest(); fastest == nil || fastest.Command != "sleep 0.01" {
+		t.Errorf("Fastest() = %+v, want the command that ran", fastest)
+	}
+
+	report.Results = report.Results[1:]
+	if fastest := report.Fastest(); fastest != nil {
+		t.Errorf("Fastest() = %+v, want nil without a successful run", fastest)
+	}
+}
+
+func TestBenchmarkOptionsStruct(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "echo 'a b'"}},
+		cmdperf.Options{Runs: 2, NoShell: true},
+	)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if report.Results[0].TotalRuns != 2 || report.Results[0].ErrorCount != 0 {
+		t.Errorf("Unexpected result: %+v", report.Results[0])
+	}
+
+	if _, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{{}}); err == nil {
+		t.Error("Expected an error for an empty command")
+	}
+	if _, err := cmdperf.Benchmark(context.Background(), nil); err == nil {
+		t.Error("Expected an error without commands")
+	}
+}
+
+func TestBenchmarkTimeout(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{cmdperf.Func("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})},
+		cmdperf.Runs(2),
+		cmdperf.Timeout(10*time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if result := report.Results[0]; result.ErrorCount != 2 || result.SuccessfulRuns != 0 {
+		t.Errorf("Timed out runs not recorded as errors: %+v", result)
+	}
+}
+
+func TestBenchmarkCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	report, err := cmdperf.Benchmark(ctx, []cmdperf.Command{{Line: "true"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+	if report == nil {
+		t.Error("Expected a partial report")
+	}
+}
+
+func TestReportMirrorsJSONOutput(t *testing.T) {
+	stats := []*benchmark.CommandStats{{
+		Command:        &command.Command{Raw: "echo hello", InShell: true, ExpectExit: []int{0, 3}, ExpectStdout: &command.Expect{Spec: "^hello$"}},
+		TotalRuns:      10,
+		SuccessfulRuns: 9,
+		ErrorCount:     1,
+		ExitCodes:      map[int]int{0: 9, 2: 1},
+		Min:            time.Millisecond,
+		Max:            3 * time.Millisecond,
+		Mean:           2 * time.Millisecond,
+		Median:         2 * time.Millisecond,
+		P50:            2 * time.Millisecond,
+		P95:            3 * time.Millisecond,
+		P99:            3 * time.Millisecond,
+		Percentiles:    []benchmark.Percentile{{P: 50, Value: 2 * time.Millisecond}, {P: 99.9, Value: 3 * time.Millisecond}},
+		StdDev:         500 * time.Microsecond,
+		Throughput:     450.5,
+		Outliers:       &benchmark.Outliers{HighSevere: 1, Samples: 9, VarianceShare: 0.75},
+		Drift:          &benchmark.Drift{Trend: 0.12, Trending: true, EndTrend: 0.05, DriftingAtEnd: true},
+		Modes:          benchmark.Modes{{Value: time.Millisecond, Weight: 0.7}, {Value: 3 * time.Millisecond, Weight: 0.3}},
+
+		ShellOverhead:       &benchmark.ShellOverhead{Shell: "/bin/sh -c", Mean: time.Millisecond, StdDev: 50 * time.Microsecond, Runs: 30},
+		MostlyShellOverhead: true,
+		InputBytes:          1 << 20,
+		InputThroughput:     25 << 20,
+		Phases:              []benchmark.Phase{{Name: benchmark.PhaseConnect, Mean: 80 * time.Microsecond, Runs: 1}, {Name: benchmark.PhaseTotal, Mean: time.Millisecond, Runs: 10}},
+		Outcomes:            benchmark.Outcomes{Success: 7, UnexpectedExit: 1, Signaled: 1, ValidationFailure: 1, ExitCodes: map[int]int{2: 1}, Signals: map[string]int{"SIGSEGV": 1}},
+		FailedRuns:          []benchmark.FailedRun{{Run: 4, Error: "stdout doesn't match ^hello$", ValidationFailed: true, Stdout: "hell\n"}},
+	}}
+
+	var cli bytes.Buffer
+	if err := (&output.JSONWriter{}).Write(&cli, stats); err != nil {
+		t.Fatalf("JSON output failed: %v", err)
+	}
+
+	var report cmdperf.Report
+	if err := json.Unmarshal([]byte(`{"version":1,"results":`+cli.String()+`}`), &report); err != nil {
+		t.Fatalf("Failed to decode the CLI's JSON as a Report: %v", err)
+	}
+
+	var fromCLI interface{}
+	_ = json.Unmarshal(cli.Bytes(), &fromCLI)
+	cliJSON, _ := json.Marshal(fromCLI)
+
+	for name, report := range map[string]*cmdperf.Report{"decoded": &report, "new": cmdperf.NewReport(stats)} {
+		var lib bytes.Buffer
+		if err := cmdperf.JSON.Write(&lib, report); err != nil {
+			t.Fatalf("JSON writer failed: %v", err)
+		}
+		var libReport map[string]interface{}
+		if err := json.Unmarshal(lib.Bytes(), &libReport); err != nil {
+			t.Fatalf("Invalid report JSON: %v", err)
+		}
+		libJSON, _ := json.Marshal(libReport["results"])
+		if !bytes.Equal(cliJSON, libJSON) {
+			t.Errorf("%s Report results differ from the JSON output:\n%s\n%s", name, cliJSON, libJSON)
+		}
+	}
+
+	if err := cmdperf.CSV.Write(io.Discard, &report); err == nil {
+		t.Error("CSV of a decoded Report succeeded")
+	}
+}
+
+func TestBenchmarkPercentiles(t *testing.T) {
+	var buf bytes.Buffer
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{cmdperf.Func("noop", func(ctx context.Context) error { return nil })},
+		cmdperf.Runs(20),
+		cmdperf.Percentiles(90, 99.9),
+		cmdperf.WriteTo(&buf, cmdperf.CSV),
+	)
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	result := report.Results[0]
+	if p, ok := result.Percentile(99.9); !ok || p != result.Max {
+		t.Errorf("Percentile(99.9) = %s, %v, want the max of 20 runs", p, ok)
+	}
+	if _, ok := result.Percentile(50); ok {
+		t.Error("Percentile(50) reported without being requested")
+	}
+	if !strings.Contains(buf.String(), "P90 (ns),P99.9 (ns)") {
+		t.Errorf("CSV output missing the percentiles:\n%s", buf.String())
+	}
+}
+
+func TestBenchmarkScheduleSeed(t *testing.T) {
+	noop := cmdperf.Func("noop", func(ctx context.Context) error { return nil })
+
+	report, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{noop, noop},
+		cmdperf.Runs(4), cmdperf.Schedule(cmdperf.ScheduleRandom))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if report.Seed == 0 {
+		t.Error("Seed = 0, want the seed picked recorded")
+	}
+
+	report, err = cmdperf.Benchmark(context.Background(), []cmdperf.Command{noop, noop},
+		cmdperf.Runs(4), cmdperf.Schedule(cmdperf.ScheduleRandom), cmdperf.Seed(42))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if report.Seed != 42 {
+		t.Errorf("Seed = %d, want 42", report.Seed)
+	}
+}
+
+func TestBenchmarkHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{cmdperf.HTTP(server.URL), cmdperf.HTTP(server.URL + "/missing")},
+		cmdperf.Runs(10), cmdperf.Concurrency(2))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	ok, missing := report.Results[0], report.Results[1]
+	if ok.Command != server.URL || ok.ErrorCount != 0 || ok.SuccessfulRuns != 10 {
+		t.Errorf("%s: %d of %d runs failed, want none", ok.Command, ok.ErrorCount, ok.TotalRuns)
+	}
+	if missing.ExitCodes[http.StatusNotFound] != 10 {
+		t.Errorf("ExitCodes = %v, want 10 runs of 404", missing.ExitCodes)
+	}
+
+	var total bool
+	for _, phase := range ok.Phases {
+		total = total || phase.Name == "total" && phase.Runs == 10
+	}
+	if !total {
+		t.Errorf("Phases = %+v, want a total of 10 runs", ok.Phases)
+	}
+
+	report, err = cmdperf.Benchmark(context.Background(), []cmdperf.Command{
+		cmdperf.HTTPRequest(http.MethodPost, server.URL+"/missing", cmdperf.HTTPOptions{ExpectStatus: []int{http.StatusNotFound}}),
+	}, cmdperf.Runs(2))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if result := report.Results[0]; result.Command != "POST "+server.URL+"/missing" || result.ErrorCount != 0 {
+		t.Errorf("%s: %d errors, want 404 expected", result.Command, result.ErrorCount)
+	}
+
+	if _, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{cmdperf.HTTP("localhost:8080")}); err == nil {
+		t.Error("Benchmark of an invalid URL succeeded")
+	}
+}
+
+func TestBenchmarkPersistentShell(t *testing.T) {
+	// The shell's variables carry over, so runs after the 20th fail
+	var buf bytes.Buffer
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: `n=$((n+1)); [ "$n" -le 20 ]`}},
+		cmdperf.Runs(25), cmdperf.Concurrency(1), cmdperf.PersistentShell(),
+		cmdperf.WriteTo(&buf, cmdperf.Markdown))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	result := report.Results[0]
+	if !result.InShell {
+		t.Error("InShell = false, want true")
+	}
+	if result.NonZeroExits != 5 {
+		t.Errorf("NonZeroExits = %d, want 5 of 25 runs in one shell", result.NonZeroExits)
+	}
+	if result.ShellOverhead != nil {
+		t.Errorf("ShellOverhead = %+v, want none without a shell per run", result.ShellOverhead)
+	}
+	if !strings.Contains(buf.String(), "- **Shell**: /bin/sh\n") || !strings.Contains(buf.String(), "Measured In-Shell") {
+		t.Errorf("Markdown output missing the shell:\n%s", buf.String())
+	}
+}
+
+func TestBenchmarkTargetPrecisionFailing(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "/nonexistent/cmdperf-test"}},
+		cmdperf.Options{TargetPrecision: 0.01, NoShell: true})
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if result := report.Results[0]; result.SuccessfulRuns != 0 || result.Converged || result.TotalRuns < 10 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+}
+
+func TestBenchmarkInput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "input")
+	if err := os.WriteFile(path, make([]byte, 1000), 0o644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: `[ "$(wc -c)" -eq 1000 ]`}},
+		cmdperf.Runs(3), cmdperf.Input(path), cmdperf.IncludeInputWrite())
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	result := report.Results[0]
+	if result.ErrorCount != 0 {
+		t.Errorf("%d errors, want the input in every run", result.ErrorCount)
+	}
+	if result.InputBytes != 1000 || result.InputThroughput <= 0 {
+		t.Errorf("InputBytes = %d, InputThroughput = %f, want 1000 bytes at some rate", result.InputBytes, result.InputThroughput)
+	}
+
+	_, err = cmdperf.Benchmark(context.Background(), []cmdperf.Command{cmdperf.TCP("127.0.0.1:1", nil)}, cmdperf.Input(path))
+	if err == nil {
+		t.Error("Benchmark fed input to a TCP command")
+	}
+}
+
+func TestBenchmarkInputFrom(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: `[ "$(wc -l)" -eq 100 ]`}},
+		cmdperf.Runs(3), cmdperf.InputFrom("seq 1 100"))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+	if result := report.Results[0]; result.ErrorCount != 0 || result.InputBytes == 0 {
+		t.Errorf("Generated input not fed to every run: %+v", result)
+	}
+}
+
+func TestBenchmarkExpectStdout(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "echo ok"}, {Line: "echo nope"}},
+		cmdperf.Runs(3), cmdperf.ExpectStdout("^ok$"))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	if failures := report.Results[0].Outcomes.ValidationFailure; failures != 0 {
+		t.Errorf("%s: %d validation failures, want none", report.Results[0].Command, failures)
+	}
+	result := report.Results[1]
+	if result.Outcomes.ValidationFailure != 3 || result.ExpectStdout != "^ok$" {
+		t.Errorf("%s: %d validation failures of %q, want 3 of ^ok$", result.Command, result.Outcomes.ValidationFailure, result.ExpectStdout)
+	}
+	if len(result.FailedRuns) == 0 || result.FailedRuns[0].Stdout != "nope\n" {
+		t.Errorf("FailedRuns = %+v, want the output of the failed runs", result.FailedRuns)
+	}
+
+	if _, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{{Line: "true"}}, cmdperf.ExpectStdout("a(")); err == nil {
+		t.Error("Benchmark succeeded with an invalid expectation")
+	}
+}
+
+func TestBenchmarkExpectExit(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "exit 3"}, {Line: "true"}, {Line: `kill -SEGV $$`}},
+		cmdperf.Runs(2), cmdperf.ExpectExit(0, 3))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	if o := report.Results[0].Outcomes; o.Success != 2 || o.Failed() != 0 {
+		t.Errorf("exit 3: Outcomes = %+v, want 2 successes", o)
+	}
+	if o := report.Results[1].Outcomes; o.Success != 2 {
+		t.Errorf("true: Outcomes = %+v, want 2 successes", o)
+	}
+	if o := report.Results[2].Outcomes; o.Signal != 2 || o.Signals["SIGSEGV"] != 2 {
+		t.Errorf("kill -SEGV: Outcomes = %+v, want 2 killed by SIGSEGV", o)
+	}
+}
+
+func TestBenchmarkSaveFailures(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "failures")
+	_, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "echo boom; exit 1"}},
+		cmdperf.Runs(3), cmdperf.SaveFailures(dir, 2))
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	runs, err := filepath.Glob(filepath.Join(dir, "1-echo-boom-exit-1", "*", "stdout"))
+	if err != nil || len(runs) != 2 {
+		t.Fatalf("Saved %v, %v, want the stdout of 2 runs", runs, err)
+	}
+	if data, err := os.ReadFile(runs[0]); err != nil || string(data) != "boom\n" {
+		t.Errorf("stdout = %q, %v, want boom", data, err)
+	}
+}
+
+func TestBenchmarkKillSignal(t *testing.T) {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: `trap 'echo cleanup; exit 0' TERM; sleep 5 & wait`}},
+		cmdperf.Runs(1), cmdperf.Timeout(200*time.Millisecond),
+		cmdperf.KillSignal(syscall.SIGTERM, time.Second), cmdperf.CaptureOutput())
+	if err != nil {
+		t.Fatalf("Benchmark failed: %v", err)
+	}
+
+	result := report.Results[0]
+	if result.Outcomes.Timeout != 1 || len(result.FailedRuns) != 1 {
+		t.Fatalf("Outcomes = %+v, FailedRuns = %+v, want a timeout", result.Outcomes, result.FailedRuns)
+	}
+	if stdout := result.FailedRuns[0].Stdout; stdout != "cleanup\n" {
+		t.Errorf("Stdout = %q, want the trap's output", stdout)
+	}
+}
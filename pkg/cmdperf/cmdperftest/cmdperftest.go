@@ -0,0 +1,147 @@
+// Package cmdperftest runs performance guardrails for commands inside go test.
+//
+//	func TestMyToolIsFast(t *testing.T) {
+//		cmdperftest.Run(t, "mytool --flag",
+//			cmdperftest.Runs(50),
+//			cmdperftest.MaxP95(200*time.Millisecond))
+//	}
+//
+// Results are logged with t.Log, and a violated limit fails the test with the
+// statistics table.
+package cmdperftest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"text/tabwriter"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/output"
+	"github.com/miklosn/cmdperf/pkg/cmdperf"
+)
+
+// Option configures Run
+type Option func(*config)
+
+type config struct {
+	options     []cmdperf.Option
+	maxMean     time.Duration
+	maxP95      time.Duration
+	maxP99      time.Duration
+	allowErrors bool
+}
+
+// Runs sets the number of runs, 10 by default
+func Runs(n int) Option {
+	return With(cmdperf.Runs(n))
+}
+
+// Concurrency sets the number of parallel runs
+func Concurrency(n int) Option {
+	return With(cmdperf.Concurrency(n))
+}
+
+// Timeout sets the timeout for a single run
+func Timeout(d time.Duration) Option {
+	return With(cmdperf.Timeout(d))
+}
+
+// With passes any cmdperf option through to the benchmark
+func With(options ...cmdperf.Option) Option {
+	return func(c *config) { c.options = append(c.options, options...) }
+}
+
+// MaxMean fails the test if the mean exceeds d
+func MaxMean(d time.Duration) Option {
+	return func(c *config) { c.maxMean = d }
+}
+
+// MaxP95 fails the test if the 95th percentile exceeds d
+func MaxP95(d time.Duration) Option {
+	return func(c *config) { c.maxP95 = d }
+}
+
+// MaxP99 fails the test if the 99th percentile exceeds d
+func MaxP99(d time.Duration) Option {
+	return func(c *config) { c.maxP99 = d }
+}
+
+// AllowErrors keeps failed runs, such as non-zero exits, from failing the
+// test. They still count towards the statistics.
+func AllowErrors() Option {
+	return func(c *config) { c.allowErrors = true }
+}
+
+// Run benchmarks line through the shell and checks the limits
+func Run(t testing.TB, line string, options ...Option) *cmdperf.Result {
+	t.Helper()
+	return RunCommand(t, cmdperf.Command{Line: line}, options...)
+}
+
+// RunCommand benchmarks cmd, which may use an Executor, and checks the limits
+func RunCommand(t testing.TB, cmd cmdperf.Command, options ...Option) *cmdperf.Result {
+	t.Helper()
+
+	c := &config{}
+	for _, option := range options {
+		option(c)
+	}
+
+	report, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{cmd}, c.options...)
+	if err != nil {
+		t.Fatalf("cmdperftest: benchmark of %q failed: %v", cmd.Line, err)
+		return nil
+	}
+	result := &report.Results[0]
+
+	table := formatTable(result)
+	if violations := c.check(result); len(violations) > 0 {
+		t.Errorf("cmdperftest: %q is too slow or failing:\n  %s\n\n%s",
+			result.Command, strings.Join(violations, "\n  "), table)
+	} else {
+		t.Logf("cmdperftest: %q\n%s", result.Command, table)
+	}
+	return result
+}
+
+// check returns a message for every limit result violates
+func (c *config) check(result *cmdperf.Result) []string {
+	var violations []string
+	if !c.allowErrors && result.ErrorCount > 0 {
+		violations = append(violations, fmt.Sprintf("%d of %d runs failed", result.ErrorCount, result.TotalRuns))
+	}
+
+	limits := []struct {
+		name  string
+		value time.Duration
+		limit time.Duration
+	}{
+		{"mean", result.Mean, c.maxMean},
+		{"p95", result.P95, c.maxP95},
+		{"p99", result.P99, c.maxP99},
+	}
+	for _, l := range limits {
+		if l.limit > 0 && l.value > l.limit {
+			violations = append(violations, fmt.Sprintf("%s %s exceeds %s",
+				l.name, output.FormatDuration(l.value), output.FormatDuration(l.limit)))
+		}
+	}
+	return violations
+}
+
+// formatTable renders result's statistics as an aligned table
+func formatTable(result *cmdperf.Result) string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  Runs\tErrors\tMean ± StdDev\tMin\tP50\tP95\tP99\tMax")
+	fmt.Fprintf(tw, "  %d\t%d\t%s ± %s\t%s\t%s\t%s\t%s\t%s\n",
+		result.TotalRuns, result.ErrorCount,
+		output.FormatDuration(result.Mean), output.FormatDuration(result.StdDev),
+		output.FormatDuration(result.Min), output.FormatDuration(result.P50),
+		output.FormatDuration(result.P95), output.FormatDuration(result.P99),
+		output.FormatDuration(result.Max))
+	tw.Flush()
+	return sb.String()
+}
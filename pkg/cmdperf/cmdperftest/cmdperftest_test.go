@@ -0,0 +1,82 @@
+package cmdperftest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miklosn/cmdperf/pkg/cmdperf"
+	"github.com/miklosn/cmdperf/pkg/cmdperf/cmdperftest"
+)
+
+// recorder captures what a test helper reports
+type recorder struct {
+	testing.TB
+	errors []string
+	logs   []string
+	fatal  bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...interface{}) {
+	r.fatal = true
+	r.Errorf(format, args...)
+}
+
+func (r *recorder) Logf(format string, args ...interface{}) {
+	r.logs = append(r.logs, fmt.Sprintf(format, args...))
+}
+
+func TestRunPasses(t *testing.T) {
+	rec := &recorder{TB: t}
+	result := cmdperftest.Run(rec, "true", cmdperftest.Runs(3), cmdperftest.MaxP95(10*time.Second))
+
+	if len(rec.errors) > 0 {
+		t.Fatalf("Unexpected failures: %v", rec.errors)
+	}
+	if result == nil || result.TotalRuns != 3 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+	if len(rec.logs) != 1 || !strings.Contains(rec.logs[0], "Mean ± StdDev") {
+		t.Errorf("Results were not logged as a table: %v", rec.logs)
+	}
+}
+
+func TestRunReportsViolations(t *testing.T) {
+	rec := &recorder{TB: t}
+	slow := cmdperf.Func("slow", func(ctx context.Context) error {
+		time.Sleep(5 * time.Millisecond)
+		return errors.New("boom")
+	})
+	cmdperftest.RunCommand(rec, slow, cmdperftest.Runs(2), cmdperftest.MaxMean(time.Millisecond))
+
+	if len(rec.errors) != 1 {
+		t.Fatalf("Expected one failure, got %v", rec.errors)
+	}
+	for _, want := range []string{"2 of 2 runs failed", "mean", "exceeds 1.00 ms", "P95"} {
+		if !strings.Contains(rec.errors[0], want) {
+			t.Errorf("Failure message missing %q:\n%s", want, rec.errors[0])
+		}
+	}
+
+	rec = &recorder{TB: t}
+	cmdperftest.RunCommand(rec, slow, cmdperftest.Runs(1), cmdperftest.AllowErrors())
+	if len(rec.errors) != 0 {
+		t.Errorf("AllowErrors still failed: %v", rec.errors)
+	}
+}
+
+func TestRunFatalOnInvalidCommand(t *testing.T) {
+	rec := &recorder{TB: t}
+	if result := cmdperftest.Run(rec, ""); result != nil || !rec.fatal {
+		t.Errorf("Expected a fatal error for an empty command, got %+v", result)
+	}
+}
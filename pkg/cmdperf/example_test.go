@@ -0,0 +1,45 @@
+package cmdperf_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/miklosn/cmdperf/pkg/cmdperf"
+)
+
+func ExampleBenchmark() {
+	report, err := cmdperf.Benchmark(context.Background(),
+		[]cmdperf.Command{{Line: "true"}, {Line: "sleep 0.01"}},
+		cmdperf.Runs(5),
+		cmdperf.WriteTo(os.Stderr, cmdperf.Markdown),
+	)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println("fastest:", report.Fastest().Command)
+	// Output: fastest: true
+}
+
+func ExampleFunc() {
+	health := cmdperf.Func("GET /health", func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:8080/health", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	})
+
+	report, err := cmdperf.Benchmark(context.Background(), []cmdperf.Command{health}, cmdperf.Concurrency(8))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(report.Results[0].P95)
+}
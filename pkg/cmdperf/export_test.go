@@ -0,0 +1,3 @@
+package cmdperf
+
+var NewReport = newReport
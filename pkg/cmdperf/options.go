@@ -0,0 +1,334 @@
+package cmdperf
+
+import (
+	"io"
+	"syscall"
+	"time"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/command"
+)
+
+// Options configures a benchmark. Zero values take the CLI's defaults.
+//
+// Options is itself an Option that replaces every setting, so a benchmark can
+// be configured either way:
+//
+//	cmdperf.Benchmark(ctx, commands, cmdperf.Options{Runs: 50, Concurrency: 4})
+//	cmdperf.Benchmark(ctx, commands, cmdperf.Runs(50), cmdperf.Concurrency(4))
+type Options struct {
+	// Runs per command, 10 by default. Ignored when Duration or
+	// TargetPrecision is set.
+	Runs int
+
+	// Duration runs every command for this long instead of Runs times
+	Duration time.Duration
+
+	// TargetPrecision runs every command until the 95% confidence interval
+	// of its mean is within ±TargetPrecision of the mean (0.01 for 1%),
+	// instead of Runs times. It takes at least MinRuns runs, 10 by default,
+	// and stops at MaxRuns runs, zero for no limit, or after MaxTime, 5
+	// minutes by default and negative for no limit. A command whose first
+	// MinRuns runs all fail stops there.
+	TargetPrecision float64
+	MinRuns         int
+	MaxRuns         int
+	MaxTime         time.Duration
+
+	// Concurrency is the number of parallel runs per command, 1 by default
+	Concurrency int
+
+	// Rate limits the runs per second of every worker, unlimited when zero
+	Rate float64
+
+	// Outliers is OutliersKeep, OutliersReport (the default) or OutliersDrop.
+	// Outliers are found with Tukey's fences among the up to 1000 samples
+	// kept for percentiles, which OutliersDrop then takes the mean, standard
+	// deviation, range and percentiles from.
+	Outliers string
+
+	// Schedule orders the runs of different commands: ScheduleParallel (the
+	// default), ScheduleSequential, ScheduleInterleaved or ScheduleRandom.
+	// Seed seeds ScheduleRandom, and is picked at random and recorded in
+	// Report.Seed when zero.
+	Schedule string
+	Seed     int64
+
+	// Percentiles to report in Result.Percentiles, such as 99.9; 50, 95 and
+	// 99 by default. P50, P95 and P99 are set either way.
+	Percentiles []float64
+
+	// Timeout for a single run, one minute by default
+	Timeout time.Duration
+
+	// KillSignal, such as syscall.SIGTERM, is sent to the process group of
+	// a command line's run at its timeout, and SIGKILL only if it still
+	// runs KillGrace later, 2 seconds by default. Runs are killed right away
+	// without it. It isn't supported on Windows.
+	KillSignal syscall.Signal
+	KillGrace  time.Duration
+
+	// Shell and ShellOptions run command lines, /bin/sh -c by default
+	// (cmd.exe /c on Windows). NoShell splits the line on spaces, respecting
+	// quotes, and executes it directly. PersistentShell runs it in one
+	// long-lived shell per worker instead of starting a process per run, for
+	// commands faster than a fork and exec, such as builtins. It isn't
+	// supported on Windows.
+	Shell           string
+	ShellOptions    []string
+	NoShell         bool
+	PersistentShell bool
+
+	// SubtractShellOverhead subtracts the time an empty command takes through
+	// the shell, measured before the benchmark, from every run of commands
+	// that use one. It is reported in Result.ShellOverhead either way.
+	SubtractShellOverhead bool
+
+	// Input is a file fed to the stdin of every run of command lines, which
+	// read it as with `command < file`. InputFrom is a command line run once
+	// through the Shell before the benchmark, whose output is fed instead.
+	// IncludeInputWrite writes the input through a pipe, so that runs
+	// include the write. Result.InputBytes and InputThroughput report its
+	// size.
+	Input             string
+	InputFrom         string
+	IncludeInputWrite bool
+
+	// ExpectExit lists the exit statuses of successful runs of command
+	// lines, 0 by default. Runs exiting with others count as
+	// Outcomes.UnexpectedExit.
+	ExpectExit []int
+
+	// CaptureOutput keeps the output of the first failed runs of command
+	// lines in Result.FailedRuns. ExpectStdout, which implies it, fails runs
+	// that exit 0 with an unexpected stdout: a regular expression to find in
+	// it, "file:<path>" for the exact contents of a file or "sha256:<hex>"
+	// of them. Outcomes.ValidationFailure counts them.
+	CaptureOutput bool
+	ExpectStdout  string
+
+	// FailuresDir, when set, is where the first FailuresLimit failed runs
+	// of every command, 10 by default, are saved for debugging, in
+	// <FailuresDir>/<n>-<command>/<run>/: run.json with how the run ended,
+	// env with the environment it ran in and, for command lines, the
+	// beginning of its stdout and stderr. Benchmark creates the directory.
+	FailuresDir   string
+	FailuresLimit int
+
+	// Outputs are written once the benchmark completes
+	Outputs []Output
+}
+
+// How outliers are treated, see Options.Outliers
+const (
+	// OutliersKeep leaves outliers in the statistics without looking for them
+	OutliersKeep = benchmark.OutliersKeep
+	// OutliersReport counts outliers in Result.Outliers
+	OutliersReport = benchmark.OutliersReport
+	// OutliersDrop also leaves them out of the statistics
+	OutliersDrop = benchmark.OutliersDrop
+)
+
+// How the runs of different commands are ordered, see Options.Schedule
+const (
+	// ScheduleParallel runs all commands at the same time
+	ScheduleParallel = benchmark.ScheduleParallel
+	// ScheduleSequential runs the commands one after another
+	ScheduleSequential = benchmark.ScheduleSequential
+	// ScheduleInterleaved takes turns between the commands, so background
+	// noise affects them alike
+	ScheduleInterleaved = benchmark.ScheduleInterleaved
+	// ScheduleRandom takes turns in a new random order every round
+	ScheduleRandom = benchmark.ScheduleRandom
+)
+
+// Output pairs a Writer with its destination
+type Output struct {
+	W      io.Writer
+	Writer Writer
+}
+
+// Option configures a benchmark, see Options
+type Option interface {
+	apply(*Options)
+}
+
+type optionFunc func(*Options)
+
+func (f optionFunc) apply(o *Options) { f(o) }
+
+func (o Options) apply(target *Options) { *target = o }
+
+// Runs sets the number of runs per command
+func Runs(n int) Option {
+	return optionFunc(func(o *Options) { o.Runs = n })
+}
+
+// Duration runs every command for d instead of a number of runs
+func Duration(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.Duration = d })
+}
+
+// TargetPrecision runs every command until its mean is known to within
+// ±precision of it (0.01 for 1%), see Options.TargetPrecision
+func TargetPrecision(precision float64) Option {
+	return optionFunc(func(o *Options) { o.TargetPrecision = precision })
+}
+
+// MaxRuns limits the runs per command with TargetPrecision
+func MaxRuns(n int) Option {
+	return optionFunc(func(o *Options) { o.MaxRuns = n })
+}
+
+// MaxTime limits the benchmark's duration with TargetPrecision
+func MaxTime(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.MaxTime = d })
+}
+
+// Concurrency sets the number of parallel runs per command
+func Concurrency(n int) Option {
+	return optionFunc(func(o *Options) { o.Concurrency = n })
+}
+
+// Rate limits the runs per second of every worker
+func Rate(perSecond float64) Option {
+	return optionFunc(func(o *Options) { o.Rate = perSecond })
+}
+
+// OutlierMode sets how outliers are treated: OutliersKeep, OutliersReport or
+// OutliersDrop
+func OutlierMode(mode string) Option {
+	return optionFunc(func(o *Options) { o.Outliers = mode })
+}
+
+// Schedule sets the order of the runs of different commands:
+// ScheduleParallel, ScheduleSequential, ScheduleInterleaved or
+// ScheduleRandom
+func Schedule(schedule string) Option {
+	return optionFunc(func(o *Options) { o.Schedule = schedule })
+}
+
+// Seed repeats the order of an earlier ScheduleRandom, see Report.Seed
+func Seed(seed int64) Option {
+	return optionFunc(func(o *Options) { o.Seed = seed })
+}
+
+// Percentiles sets the percentiles to report, such as 99.9
+func Percentiles(percentiles ...float64) Option {
+	return optionFunc(func(o *Options) { o.Percentiles = percentiles })
+}
+
+// Timeout sets the timeout for a single run
+func Timeout(d time.Duration) Option {
+	return optionFunc(func(o *Options) { o.Timeout = d })
+}
+
+// Shell runs command lines with shell and options, such as "bash", "-c"
+func Shell(shell string, options ...string) Option {
+	return optionFunc(func(o *Options) {
+		o.Shell = shell
+		o.ShellOptions = options
+	})
+}
+
+// NoShell executes command lines directly instead of through a shell
+func NoShell() Option {
+	return optionFunc(func(o *Options) { o.NoShell = true })
+}
+
+// PersistentShell runs command lines in persistent shells instead of
+// starting a process per run
+func PersistentShell() Option {
+	return optionFunc(func(o *Options) { o.PersistentShell = true })
+}
+
+// SubtractShellOverhead subtracts the overhead of starting the shell from
+// every run
+func SubtractShellOverhead() Option {
+	return optionFunc(func(o *Options) { o.SubtractShellOverhead = true })
+}
+
+// Input feeds the file at path to the stdin of every run, see Options.Input
+func Input(path string) Option {
+	return optionFunc(func(o *Options) { o.Input = path })
+}
+
+// InputFrom feeds the output of line, run once through the shell, to the
+// stdin of every run, see Options.InputFrom
+func InputFrom(line string) Option {
+	return optionFunc(func(o *Options) { o.InputFrom = line })
+}
+
+// IncludeInputWrite writes the input to every run through a pipe, timing the
+// write
+func IncludeInputWrite() Option {
+	return optionFunc(func(o *Options) { o.IncludeInputWrite = true })
+}
+
+// KillSignal sends sig to runs at their timeout, and kills them grace later,
+// see Options.KillSignal
+func KillSignal(sig syscall.Signal, grace time.Duration) Option {
+	return optionFunc(func(o *Options) {
+		o.KillSignal = sig
+		o.KillGrace = grace
+	})
+}
+
+// ExpectExit sets the exit statuses of successful runs, see
+// Options.ExpectExit
+func ExpectExit(codes ...int) Option {
+	return optionFunc(func(o *Options) { o.ExpectExit = codes })
+}
+
+// CaptureOutput keeps the output of the first failed runs, see
+// Options.CaptureOutput
+func CaptureOutput() Option {
+	return optionFunc(func(o *Options) { o.CaptureOutput = true })
+}
+
+// ExpectStdout fails runs whose stdout doesn't match spec, see
+// Options.ExpectStdout
+func ExpectStdout(spec string) Option {
+	return optionFunc(func(o *Options) { o.ExpectStdout = spec })
+}
+
+// SaveFailures saves up to limit failed runs of every command to dir, see
+// Options.FailuresDir
+func SaveFailures(dir string, limit int) Option {
+	return optionFunc(func(o *Options) {
+		o.FailuresDir = dir
+		o.FailuresLimit = limit
+	})
+}
+
+// WriteTo writes the report to w with writer once the benchmark completes.
+// It may be given several times.
+func WriteTo(w io.Writer, writer Writer) Option {
+	return optionFunc(func(o *Options) {
+		o.Outputs = append(o.Outputs, Output{W: w, Writer: writer})
+	})
+}
+
+func (o *Options) setDefaults() {
+	if o.Runs <= 0 {
+		o.Runs = 10
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Minute
+	}
+	if o.TargetPrecision > 0 && o.MaxTime == 0 {
+		o.MaxTime = benchmark.DefaultMaxTime
+	}
+	if o.KillGrace <= 0 {
+		o.KillGrace = 2 * time.Second
+	}
+	if o.Shell == "" {
+		o.Shell = command.DefaultShell
+		if o.ShellOptions == nil {
+			o.ShellOptions = []string{command.DefaultShellOption}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package cmdperf
+
+import (
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/output"
+)
+
+// ReportVersion is the version of the Report layout. It is incremented when
+// a field is removed or changes meaning; new fields don't change it.
+const ReportVersion = 1
+
+// Report holds the results of a benchmark. It marshals to JSON as
+//
+//	{"version": 1, "results": [...]}
+//
+// where every result has the same keys as an element of the CLI's --json
+// output, so Results can also be decoded from such a file.
+type Report struct {
+	Version int      `json:"version"`
+	Results []Result `json:"results"`
+
+	// Seed is the seed of ScheduleRandom, which Options.Seed repeats the
+	// order of the runs with
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// Result holds one command's statistics, with the same JSON keys as an
+// element of the CLI's --json output, which encodes the same type
+type Result = output.Result
+
+// The parts of a Result
+type (
+	// Outliers counts the samples outside Tukey's fences, see
+	// Options.Outliers
+	Outliers = output.Outliers
+
+	// ShellOverhead is the time an empty command takes through a shell, see
+	// Options.SubtractShellOverhead
+	ShellOverhead = output.ShellOverhead
+
+	// Mode is a peak of a latency distribution
+	Mode = output.Mode
+
+	// Phase is the mean duration of a phase of HTTP requests
+	Phase = output.Phase
+
+	// Outcomes counts runs by how they ended, see Options.ExpectExit and
+	// Options.ExpectStdout
+	Outcomes = output.Outcomes
+
+	// FailedRun is the captured output of a failed run, see
+	// Options.CaptureOutput
+	FailedRun = output.FailedRun
+
+	// Drift describes how latency changed over the course of a benchmark
+	Drift = output.Drift
+)
+
+// Fastest returns the result with the lowest mean, leaving out commands
+// without a successful run, or nil if there are none
+func (r *Report) Fastest() *Result {
+	var fastest *Result
+	for i := range r.Results {
+		if r.Results[i].SuccessfulRuns == 0 {
+			continue
+		}
+		if fastest == nil || r.Results[i].Mean < fastest.Mean {
+			fastest = &r.Results[i]
+		}
+	}
+	return fastest
+}
+
+// newReport converts the runner's statistics to a Report
+func newReport(stats []*benchmark.CommandStats) *Report {
+	return &Report{Version: ReportVersion, Results: output.NewResults(stats)}
+}
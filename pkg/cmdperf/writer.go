@@ -0,0 +1,61 @@
+package cmdperf
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/miklosn/cmdperf/internal/benchmark"
+	"github.com/miklosn/cmdperf/internal/output"
+)
+
+// Writer renders a Report. Implement it to add formats of your own.
+type Writer interface {
+	Write(w io.Writer, report *Report) error
+}
+
+// WriterFunc adapts a function to a Writer
+type WriterFunc func(w io.Writer, report *Report) error
+
+// Write calls f(w, report)
+func (f WriterFunc) Write(w io.Writer, report *Report) error {
+	return f(w, report)
+}
+
+// The built-in writers, producing the same output as the CLI
+var (
+	// JSON writes the Report itself, with its version
+	JSON Writer = WriterFunc(writeJSON)
+
+	// CSV, Markdown and Terminal match --csv, --markdown and the CLI's final
+	// results, colored only when stdout is a terminal. They write Reports
+	// returned by Benchmark, not decoded ones.
+	CSV      Writer = statsWriter{&output.CSVWriter{}}
+	Markdown Writer = statsWriter{&output.MarkdownWriter{}}
+	Terminal Writer = statsWriter{&output.TerminalWriter{}}
+)
+
+func writeJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("failed to write JSON: %w", err)
+	}
+	return nil
+}
+
+// statsWriter adapts the CLI's writers, which render the statistics the
+// results were made from
+type statsWriter struct {
+	writer output.Writer
+}
+
+func (s statsWriter) Write(w io.Writer, report *Report) error {
+	stats := make([]*benchmark.CommandStats, len(report.Results))
+	for i := range report.Results {
+		if stats[i] = output.ResultStats(&report.Results[i]); stats[i] == nil {
+			return fmt.Errorf("cmdperf: result %d wasn't returned by Benchmark", i+1)
+		}
+	}
+	return s.writer.Write(w, stats)
+}